@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/handler"
+	"policy-inference-decider/internal/policy"
+)
+
+const (
+	// batchParseCacheSize bounds how many distinct policies a batch run keeps parsed.
+	batchParseCacheSize = 64
+	maxRequestLineBytes = 10 << 20
+)
+
+var errPolicyIDUnsupported = errors.New("policy_id requires the policy registry; inline policy_dot instead")
+
+type (
+	// result is one output line: the inference response, or the error of that request.
+	result struct {
+		Line int `json:"line,omitempty"`
+		*policy.InferResponse
+		Error *apierror.APIError `json:"error,omitempty"`
+	}
+
+	evaluator struct {
+		parser   policy.Parser
+		executor policy.Executor
+	}
+)
+
+func newEvaluator() *evaluator {
+	return &evaluator{
+		parser:   handler.NewCachedParser(policy.NewDotParser(nil), batchParseCacheSize, 0),
+		executor: policy.NewGraphExecutor(nil),
+	}
+}
+
+func (e *evaluator) evaluate(ctx context.Context, req policy.InferRequest) result {
+	if req.PolicyID != "" {
+		apiError := apierror.NewInvalidRequestBodyError()
+		apiError.Message = errPolicyIDUnsupported.Error()
+		return result{Error: &apiError}
+	}
+	graph, err := e.parser.Parse(ctx, req.PolicyDOT)
+	if err != nil {
+		apiError := handler.ErrorFromParseDOT(err)
+		return result{Error: &apiError}
+	}
+	resp, err := e.executor.Process(ctx, graph, req.Input, req.Options())
+	if err != nil {
+		apiError := handler.ErrorFromPolicy(err)
+		return result{Error: &apiError}
+	}
+	return result{InferResponse: &resp}
+}
+
+func runEval(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	policyPath := fs.String("policy", "", "path to the policy DOT file")
+	inputPath := fs.String("input", "", "path to a JSON object with the input variables")
+	explain := fs.Bool("explain", false, "include the execution trace")
+	strict := fs.Bool("strict", false, "fail on cycles instead of stopping")
+	maxSteps := fs.Int("max-steps", 0, "step budget for loop-aware execution")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *policyPath == "" || *inputPath == "" {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	dot, err := os.ReadFile(*policyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "read policy: %v\n", err)
+		return exitError
+	}
+	rawInput, err := os.ReadFile(*inputPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "read input: %v\n", err)
+		return exitError
+	}
+	var input map[string]any
+	if err := json.Unmarshal(rawInput, &input); err != nil {
+		fmt.Fprintf(stderr, "decode input: %v\n", err)
+		return exitError
+	}
+
+	req := policy.InferRequest{PolicyDOT: string(dot), Input: input, Explain: *explain, Strict: *strict, MaxSteps: *maxSteps}
+	res := newEvaluator().evaluate(context.Background(), req)
+	if err := writeResult(stdout, res); err != nil {
+		fmt.Fprintf(stderr, "write output: %v\n", err)
+		return exitError
+	}
+	if res.Error != nil {
+		fmt.Fprintf(stderr, "error: %s: %s\n", res.Error.ErrorCode, res.Error.Message)
+		return exitError
+	}
+	return exitOK
+}
+
+func runBatch(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	requestsPath := fs.String("requests", "", "path to a JSONL file with one InferRequest per line")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *requestsPath == "" {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	f, err := os.Open(*requestsPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "open requests: %v\n", err)
+		return exitError
+	}
+	defer f.Close()
+
+	eval := newEvaluator()
+	errorsByCode := make(map[string]int)
+	total := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		total++
+		var req policy.InferRequest
+		res := result{}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			apiError := apierror.NewInvalidRequestBodyError()
+			res.Error = &apiError
+		} else {
+			res = eval.evaluate(context.Background(), req)
+		}
+		res.Line = line
+		if res.Error != nil {
+			errorsByCode[res.Error.ErrorCode]++
+		}
+		if err := writeResult(stdout, res); err != nil {
+			fmt.Fprintf(stderr, "write output: %v\n", err)
+			return exitError
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "read requests: %v\n", err)
+		return exitError
+	}
+	writeSummary(stderr, total, errorsByCode)
+	return exitOK
+}
+
+func writeResult(w io.Writer, res result) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+func writeSummary(w io.Writer, total int, errorsByCode map[string]int) {
+	failed := 0
+	codes := make([]string, 0, len(errorsByCode))
+	for code, n := range errorsByCode {
+		codes = append(codes, code)
+		failed += n
+	}
+	sort.Strings(codes)
+	fmt.Fprintf(w, "processed %d requests: %d ok, %d failed\n", total, total-failed, failed)
+	for _, code := range codes {
+		fmt.Fprintf(w, "  %s: %d\n", code, errorsByCode[code])
+	}
+}
@@ -0,0 +1,50 @@
+// Command pid evaluates policies offline, reusing the same parser and executor as the service.
+//
+//	pid eval --policy policy.dot --input input.json [--explain] [--strict] [--max-steps N]
+//	pid batch --requests requests.jsonl
+//
+// Both subcommands write one JSON result per request to stdout; batch also prints a summary of
+// errors grouped by apierror code to stderr.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+const usage = `usage:
+  pid eval --policy <file.dot> --input <input.json> [--explain] [--strict] [--max-steps N]
+  pid batch --requests <requests.jsonl>
+`
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	slog.SetLogLoggerLevel(slog.LevelWarn)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "eval":
+		return runEval(args[1:], stdout, stderr)
+	case "batch":
+		return runBatch(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+		return exitUsage
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/apierror"
+)
+
+const exampleDOT = `digraph { start [result=""]; ok [result="approved=true"]; no [result="approved=false"]; start -> ok [cond="age>=18"]; start -> no [cond="age<18"]; }`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func decodeLines(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestRunEval(t *testing.T) {
+	t.Run("prints the inference response", func(t *testing.T) {
+		// Arrange
+		policyPath := writeFile(t, "policy.dot", exampleDOT)
+		inputPath := writeFile(t, "input.json", `{"age": 20}`)
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"eval", "--policy", policyPath, "--input", inputPath}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitOK, code)
+		lines := decodeLines(t, stdout.String())
+		require.Len(t, lines, 1)
+		assert.Equal(t, "ok", lines[0]["node"])
+		assert.Equal(t, map[string]any{"age": float64(20), "approved": true}, lines[0]["output"])
+	})
+	t.Run("policy error prints apierror and exits 1", func(t *testing.T) {
+		// Arrange
+		policyPath := writeFile(t, "policy.dot", `digraph { foo; }`)
+		inputPath := writeFile(t, "input.json", `{}`)
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"eval", "--policy", policyPath, "--input", inputPath}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitError, code)
+		lines := decodeLines(t, stdout.String())
+		assert.Equal(t, apierror.CodePolicyNoStartNode, lines[0]["error"].(map[string]any)["error"])
+		assert.Contains(t, stderr.String(), apierror.CodePolicyNoStartNode)
+	})
+	t.Run("missing flags print usage", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"eval", "--policy", "x.dot"}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr.String(), "usage:")
+	})
+	t.Run("unreadable files and bad input JSON exit 1", func(t *testing.T) {
+		// Arrange
+		policyPath := writeFile(t, "policy.dot", exampleDOT)
+		badInput := writeFile(t, "input.json", `[1,2]`)
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		missingPolicy := run([]string{"eval", "--policy", "missing.dot", "--input", badInput}, &stdout, &stderr)
+		missingInput := run([]string{"eval", "--policy", policyPath, "--input", "missing.json"}, &stdout, &stderr)
+		decodeErr := run([]string{"eval", "--policy", policyPath, "--input", badInput}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitError, missingPolicy)
+		assert.Equal(t, exitError, missingInput)
+		assert.Equal(t, exitError, decodeErr)
+	})
+}
+
+func TestRunBatch(t *testing.T) {
+	t.Run("writes one line per request and a summary by error code", func(t *testing.T) {
+		// Arrange
+		lines := []string{
+			`{"policy_dot":` + mustJSON(t, exampleDOT) + `,"input":{"age":20}}`,
+			``,
+			`{"policy_dot":` + mustJSON(t, exampleDOT) + `,"input":{"age":10}}`,
+			`not json`,
+			`{"policy_dot":"digraph { foo; }","input":{}}`,
+			`{"policy_id":"credit","input":{}}`,
+		}
+		requestsPath := writeFile(t, "requests.jsonl", strings.Join(lines, "\n"))
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"batch", "--requests", requestsPath}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitOK, code)
+		got := decodeLines(t, stdout.String())
+		require.Len(t, got, 5)
+		assert.Equal(t, float64(1), got[0]["line"])
+		assert.Equal(t, "ok", got[0]["node"])
+		assert.Equal(t, float64(3), got[1]["line"])
+		assert.Equal(t, "no", got[1]["node"])
+		assert.Equal(t, float64(4), got[2]["line"])
+		assert.NotNil(t, got[2]["error"])
+		assert.Equal(t, "processed 5 requests: 2 ok, 3 failed\n  invalid_request_body: 2\n  policy_no_start_node: 1\n", stderr.String())
+	})
+	t.Run("missing file exits 1", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"batch", "--requests", filepath.Join(t.TempDir(), "missing.jsonl")}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitError, code)
+	})
+	t.Run("missing flag prints usage", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"batch"}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code)
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("no command prints usage", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run(nil, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code)
+	})
+	t.Run("unknown command prints usage", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"deploy"}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr.String(), `unknown command "deploy"`)
+	})
+	t.Run("help prints usage to stdout", func(t *testing.T) {
+		// Arrange
+		var stdout, stderr bytes.Buffer
+
+		// Act
+		code := run([]string{"help"}, &stdout, &stderr)
+
+		// Assert
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stdout.String(), "pid batch")
+	})
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
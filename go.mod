@@ -12,6 +12,5 @@ require (
 
 require (
 	github.com/awalterschulze/gographviz v2.0.3+incompatible
-	github.com/casbin/govaluate v1.10.0
 	github.com/stretchr/testify v1.10.0
 )
@@ -1,12 +1,25 @@
 package apierror
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 const (
 	CodeInvalidRequestBody = "invalid_request_body"
+	CodeBatchTooLarge      = "batch_too_large"
+	CodeRequestTooLarge    = "request_too_large"
 	CodeInvalidPolicyDOT   = "invalid_policy_dot"
 	CodePolicyNoStartNode  = "policy_no_start_node"
 	CodeInvalidCondition   = "invalid_condition"
+	CodeInvalidResult      = "invalid_result"
+	CodeArithmeticError    = "arithmetic_error"
+	CodeCycleDetected      = "cycle_detected"
+	CodeStepBudgetExceeded = "step_budget_exceeded"
+	CodeTimeout            = "timeout"
+	CodePolicyNotFound     = "policy_not_found"
+	CodeInvalidPolicyID    = "invalid_policy_id"
 	CodeInternalError      = "internal_error"
 	CodeNotFound           = "not_found"
 	CodeMethodNotAllowed   = "method_not_allowed"
@@ -14,9 +27,19 @@ const (
 
 const (
 	msgInvalidRequestBody = "Invalid request body."
+	msgBatchTooLarge      = "Batch exceeds the maximum of %d inputs."
+	msgRequestTooLarge    = "Request body too large."
 	msgInvalidPolicyDOT   = "Invalid policy DOT format."
 	msgPolicyNoStartNode  = "Policy graph has no start node."
 	msgInvalidCondition   = "Invalid condition in policy."
+	msgConditionDetail    = "Invalid condition in policy: %s."
+	msgInvalidResult      = "Invalid result in policy: %s."
+	msgArithmeticError    = "Arithmetic error evaluating policy: %s."
+	msgCycleDetected      = "Cycle detected in policy graph: %s."
+	msgStepBudgetExceeded = "Policy execution exceeded its step budget."
+	msgTimeout            = "Policy evaluation timed out."
+	msgPolicyNotFound     = "Policy or policy version not found."
+	msgInvalidPolicyID    = "Invalid policy id."
 	msgInternalError      = "An internal error occurred."
 	msgNotFound           = "Not found."
 	msgMethodNotAllowed   = "Method not allowed."
@@ -32,6 +55,14 @@ func NewInvalidRequestBodyError() APIError {
 	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidRequestBody, Message: msgInvalidRequestBody}
 }
 
+func NewBatchTooLargeError(limit int) APIError {
+	return APIError{Status: http.StatusRequestEntityTooLarge, ErrorCode: CodeBatchTooLarge, Message: fmt.Sprintf(msgBatchTooLarge, limit)}
+}
+
+func NewRequestTooLargeError() APIError {
+	return APIError{Status: http.StatusRequestEntityTooLarge, ErrorCode: CodeRequestTooLarge, Message: msgRequestTooLarge}
+}
+
 func NewInvalidPolicyDotError() APIError {
 	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidPolicyDOT, Message: msgInvalidPolicyDOT}
 }
@@ -44,6 +75,38 @@ func NewInvalidConditionError() APIError {
 	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidCondition, Message: msgInvalidCondition}
 }
 
+func NewInvalidConditionDetailError(detail string) APIError {
+	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidCondition, Message: fmt.Sprintf(msgConditionDetail, detail)}
+}
+
+func NewInvalidResultError(detail string) APIError {
+	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidResult, Message: fmt.Sprintf(msgInvalidResult, detail)}
+}
+
+func NewArithmeticError(detail string) APIError {
+	return APIError{Status: http.StatusUnprocessableEntity, ErrorCode: CodeArithmeticError, Message: fmt.Sprintf(msgArithmeticError, detail)}
+}
+
+func NewCycleDetectedError(path []string) APIError {
+	return APIError{Status: http.StatusUnprocessableEntity, ErrorCode: CodeCycleDetected, Message: fmt.Sprintf(msgCycleDetected, strings.Join(path, " -> "))}
+}
+
+func NewStepBudgetExceededError() APIError {
+	return APIError{Status: http.StatusUnprocessableEntity, ErrorCode: CodeStepBudgetExceeded, Message: msgStepBudgetExceeded}
+}
+
+func NewTimeoutError() APIError {
+	return APIError{Status: http.StatusGatewayTimeout, ErrorCode: CodeTimeout, Message: msgTimeout}
+}
+
+func NewPolicyNotFoundError() APIError {
+	return APIError{Status: http.StatusNotFound, ErrorCode: CodePolicyNotFound, Message: msgPolicyNotFound}
+}
+
+func NewInvalidPolicyIDError() APIError {
+	return APIError{Status: http.StatusBadRequest, ErrorCode: CodeInvalidPolicyID, Message: msgInvalidPolicyID}
+}
+
 func NewInternalError() APIError {
 	return APIError{Status: http.StatusInternalServerError, ErrorCode: CodeInternalError, Message: msgInternalError}
 }
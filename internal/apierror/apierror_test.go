@@ -19,6 +19,30 @@ func TestNewInvalidRequestBodyError(t *testing.T) {
 	})
 }
 
+func TestNewBatchTooLargeError(t *testing.T) {
+	t.Run("returns correct status, code and limit in message", func(t *testing.T) {
+		// Act
+		e := NewBatchTooLargeError(1000)
+
+		// Assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, e.Status)
+		assert.Equal(t, CodeBatchTooLarge, e.ErrorCode)
+		assert.Equal(t, "Batch exceeds the maximum of 1000 inputs.", e.Message)
+	})
+}
+
+func TestNewRequestTooLargeError(t *testing.T) {
+	t.Run("returns correct status and codes", func(t *testing.T) {
+		// Act
+		e := NewRequestTooLargeError()
+
+		// Assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, e.Status)
+		assert.Equal(t, CodeRequestTooLarge, e.ErrorCode)
+		assert.Equal(t, "Request body too large.", e.Message)
+	})
+}
+
 func TestNewInvalidPolicyDotError(t *testing.T) {
 	t.Run("returns correct status and codes", func(t *testing.T) {
 		// Act
@@ -55,6 +79,102 @@ func TestNewInvalidConditionError(t *testing.T) {
 	})
 }
 
+func TestNewInvalidConditionDetailError(t *testing.T) {
+	t.Run("returns correct status, code and detail in message", func(t *testing.T) {
+		// Act
+		e := NewInvalidConditionDetailError(`"a <= b": variable b is not set at column 6`)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+		assert.Equal(t, CodeInvalidCondition, e.ErrorCode)
+		assert.Equal(t, `Invalid condition in policy: "a <= b": variable b is not set at column 6.`, e.Message)
+	})
+}
+
+func TestNewInvalidResultError(t *testing.T) {
+	t.Run("returns correct status, code and detail in message", func(t *testing.T) {
+		// Act
+		e := NewInvalidResultError(`"approved=true, oops": expected = after "oops" at column 16`)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+		assert.Equal(t, CodeInvalidResult, e.ErrorCode)
+		assert.Equal(t, `Invalid result in policy: "approved=true, oops": expected = after "oops" at column 16.`, e.Message)
+	})
+}
+
+func TestNewArithmeticError(t *testing.T) {
+	t.Run("returns correct status, code and detail in message", func(t *testing.T) {
+		// Act
+		e := NewArithmeticError(`"debt / income < 0.4": division by zero in 100 / 0 at column 6`)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, e.Status)
+		assert.Equal(t, CodeArithmeticError, e.ErrorCode)
+		assert.Equal(t, `Arithmetic error evaluating policy: "debt / income < 0.4": division by zero in 100 / 0 at column 6.`, e.Message)
+	})
+}
+
+func TestNewCycleDetectedError(t *testing.T) {
+	t.Run("returns correct status, code and path in message", func(t *testing.T) {
+		// Act
+		e := NewCycleDetectedError([]string{"start", "a", "start"})
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, e.Status)
+		assert.Equal(t, CodeCycleDetected, e.ErrorCode)
+		assert.Equal(t, "Cycle detected in policy graph: start -> a -> start.", e.Message)
+	})
+}
+
+func TestNewStepBudgetExceededError(t *testing.T) {
+	t.Run("returns correct status and codes", func(t *testing.T) {
+		// Act
+		e := NewStepBudgetExceededError()
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, e.Status)
+		assert.Equal(t, CodeStepBudgetExceeded, e.ErrorCode)
+		assert.Equal(t, "Policy execution exceeded its step budget.", e.Message)
+	})
+}
+
+func TestNewTimeoutError(t *testing.T) {
+	t.Run("returns correct status and codes", func(t *testing.T) {
+		// Act
+		e := NewTimeoutError()
+
+		// Assert
+		assert.Equal(t, http.StatusGatewayTimeout, e.Status)
+		assert.Equal(t, CodeTimeout, e.ErrorCode)
+		assert.Equal(t, "Policy evaluation timed out.", e.Message)
+	})
+}
+
+func TestNewPolicyNotFoundError(t *testing.T) {
+	t.Run("returns correct status and codes", func(t *testing.T) {
+		// Act
+		e := NewPolicyNotFoundError()
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, e.Status)
+		assert.Equal(t, CodePolicyNotFound, e.ErrorCode)
+		assert.Equal(t, "Policy or policy version not found.", e.Message)
+	})
+}
+
+func TestNewInvalidPolicyIDError(t *testing.T) {
+	t.Run("returns correct status and codes", func(t *testing.T) {
+		// Act
+		e := NewInvalidPolicyIDError()
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+		assert.Equal(t, CodeInvalidPolicyID, e.ErrorCode)
+		assert.Equal(t, "Invalid policy id.", e.Message)
+	})
+}
+
 func TestNewInternalError(t *testing.T) {
 	t.Run("returns correct status and codes", func(t *testing.T) {
 		// Act
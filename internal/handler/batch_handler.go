@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/policy"
+)
+
+const (
+	maxBatchInputs = 1000
+	batchWorkers   = 8
+)
+
+type (
+	// batchItemResult carries either the inference response or the error of one input, in input order.
+	batchItemResult struct {
+		*policy.InferResponse
+		Error *apierror.APIError `json:"error,omitempty"`
+	}
+
+	batchInferResponse struct {
+		Results []batchItemResult `json:"results"`
+	}
+)
+
+func (h *Handler) inferBatch(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+	var body policy.BatchInferRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference_batch] [msg:bind_json] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromBindJSON)
+	}
+	if len(body.Inputs) > maxBatchInputs {
+		return jsonErrorResponseURL(apierror.NewBatchTooLargeError(maxBatchInputs))
+	}
+
+	dot, err := h.resolvePolicyDOT(ctx, body.PolicyDOT, body.PolicyID, body.Version)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference_batch] [msg:resolve_policy] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromStore)
+	}
+
+	graph, err := h.parser.Parse(ctx, dot)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference_batch] [msg:parse_dot] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, ErrorFromParseDOT)
+	}
+
+	results := h.processBatch(ctx, req.RequestContext.RequestID, graph, body.Inputs, body.Options())
+	return jsonResponseURL(http.StatusOK, batchInferResponse{Results: results})
+}
+
+// processBatch evaluates inputs on at most batchWorkers goroutines; a failing input only fails its own result.
+func (h *Handler) processBatch(ctx context.Context, requestID string, graph *policy.Graph, inputs []map[string]any, opts policy.ExecOptions) []batchItemResult {
+	results := make([]batchItemResult, len(inputs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(inputs)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = h.processBatchItem(ctx, requestID, i, graph, inputs[i], opts)
+			}
+		})
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (h *Handler) processBatchItem(ctx context.Context, requestID string, index int, graph *policy.Graph, input map[string]any, opts policy.ExecOptions) batchItemResult {
+	resp, err := h.executor.Process(ctx, graph, input, opts)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference_batch] [msg:execute] [request_id:%s] [index:%d] [err:%+v]", requestID, index, err))
+		apiError := ErrorFromPolicy(err)
+		return batchItemResult{Error: &apiError}
+	}
+	return batchItemResult{InferResponse: &resp}
+}
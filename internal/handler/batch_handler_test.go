@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
+)
+
+type batchResponseBody struct {
+	Results []struct {
+		Output map[string]any     `json:"output"`
+		Node   string             `json:"node"`
+		Error  *apierror.APIError `json:"error"`
+	} `json:"results"`
+}
+
+func bodyFromBatchRequest(r policy.BatchInferRequest) string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func TestInferBatch(t *testing.T) {
+	t.Run("evaluates every input in order", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		inputs := make([]map[string]any, 20)
+		for i := range inputs {
+			inputs[i] = map[string]any{"age": 10 + i}
+		}
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyDOT: exampleDOT, Inputs: inputs})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out batchResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		require.Len(t, out.Results, len(inputs))
+		for i, result := range out.Results {
+			assert.Equal(t, float64(10+i), result.Output["age"])
+			assert.Equal(t, 10+i >= 18, result.Output["approved"])
+			assert.Nil(t, result.Error)
+		}
+	})
+	t.Run("bad input fails only its own item", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyDOT: exampleDOT, Inputs: []map[string]any{{"age": 20}, {}, {"age": 15}}})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out batchResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		require.Len(t, out.Results, 3)
+		assert.Equal(t, "ok", out.Results[0].Node)
+		require.NotNil(t, out.Results[1].Error)
+		assert.Equal(t, apierror.CodeInvalidCondition, out.Results[1].Error.ErrorCode)
+		assert.Nil(t, out.Results[1].Output)
+		assert.Equal(t, "no", out.Results[2].Node)
+	})
+	t.Run("policy_id resolves the policy once for the batch", func(t *testing.T) {
+		// Arrange
+		store := registry.NewMemoryStore()
+		_, err := store.Put(context.Background(), "credit", policyChallengeDOT)
+		require.NoError(t, err)
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), store)
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyID: "credit", Inputs: []map[string]any{{"age": 25, "score": 720}}})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		var out batchResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		require.Len(t, out.Results, 1)
+		assert.Equal(t, "prime", out.Results[0].Output["segment"])
+	})
+	t.Run("empty batch returns empty results", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyDOT: exampleDOT})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"results":[]}`, resp.Body)
+	})
+	t.Run("invalid policy fails the whole batch", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyDOT: dotNoStart, Inputs: []map[string]any{{"x": 1}}})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodePolicyNoStartNode, apiErr.Error)
+	})
+	t.Run("unknown policy_id returns policy_not_found", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromBatchRequest(policy.BatchInferRequest{PolicyID: "ghost", Inputs: []map[string]any{{"x": 1}}})
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("invalid JSON returns invalid_request_body", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		req := makeURLRequest("{", http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("batch over the limit returns batch_too_large", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := `{"policy_dot":"digraph { start; }","inputs":[` + strings.Repeat(`{},`, maxBatchInputs) + `{}]}`
+		req := makeURLRequest(body, http.MethodPost, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeBatchTooLarge, apiErr.Error)
+	})
+	t.Run("GET /infer/batch returns 405", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		req := makeURLRequest("", http.MethodGet, "/infer/batch")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
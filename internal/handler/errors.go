@@ -8,6 +8,7 @@ import (
 
 	"policy-inference-decider/internal/apierror"
 	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
 )
 
 type APIError struct {
@@ -17,31 +18,85 @@ type APIError struct {
 }
 
 func jsonErrorResponseURL(apiError apierror.APIError) events.LambdaFunctionURLResponse {
-	responseBody, _ := json.Marshal(apiError)
+	return jsonResponseURL(apiError.Status, apiError)
+}
+
+func jsonResponseURL(status int, body any) events.LambdaFunctionURLResponse {
+	responseBody, _ := json.Marshal(body)
 	return events.LambdaFunctionURLResponse{
-		StatusCode: apiError.Status,
+		StatusCode: status,
 		Headers:    map[string]string{"Content-Type": "application/json"},
 		Body:       string(responseBody),
 	}
 }
 
-func errorFromPolicy(err error) apierror.APIError {
+// ErrorFromPolicy maps an execution error from policy.Executor to its API error.
+func ErrorFromPolicy(err error) apierror.APIError {
 	if errors.Is(err, policy.ErrNoStartNode) {
 		return apierror.NewNoStartNodeError()
 	}
+	var condErr *policy.ConditionError
+	if errors.As(err, &condErr) {
+		if errors.Is(err, policy.ErrDivisionByZero) || errors.Is(err, policy.ErrArithmeticOverflow) {
+			return apierror.NewArithmeticError(condErr.Detail())
+		}
+		return apierror.NewInvalidConditionDetailError(condErr.Detail())
+	}
+	var resultErr *policy.ResultError
+	if errors.As(err, &resultErr) {
+		if errors.Is(err, policy.ErrDivisionByZero) || errors.Is(err, policy.ErrArithmeticOverflow) {
+			return apierror.NewArithmeticError(resultErr.Detail())
+		}
+		return apierror.NewInvalidResultError(resultErr.Detail())
+	}
 	if errors.Is(err, policy.ErrInvalidCondition) {
 		return apierror.NewInvalidConditionError()
 	}
+	var cycleErr *policy.CycleError
+	if errors.As(err, &cycleErr) {
+		return apierror.NewCycleDetectedError(cycleErr.Path)
+	}
+	if errors.Is(err, policy.ErrStepBudgetExceeded) {
+		return apierror.NewStepBudgetExceededError()
+	}
+	if errors.Is(err, policy.ErrTimeout) {
+		return apierror.NewTimeoutError()
+	}
 	return apierror.NewInternalError()
 }
 
-func errorFromParseDOT(err error) apierror.APIError {
+// ErrorFromParseDOT maps an error from policy.Parser to its API error.
+func ErrorFromParseDOT(err error) apierror.APIError {
 	if errors.Is(err, policy.ErrNoStartNode) {
 		return apierror.NewNoStartNodeError()
 	}
+	var condErr *policy.ConditionError
+	if errors.As(err, &condErr) {
+		return apierror.NewInvalidConditionDetailError(condErr.Detail())
+	}
+	var resultErr *policy.ResultError
+	if errors.As(err, &resultErr) {
+		return apierror.NewInvalidResultError(resultErr.Detail())
+	}
+	if errors.Is(err, policy.ErrTimeout) {
+		return apierror.NewTimeoutError()
+	}
 	return apierror.NewInvalidPolicyDotError()
 }
 
+func errorFromStore(err error) apierror.APIError {
+	if errors.Is(err, registry.ErrPolicyNotFound) || errors.Is(err, registry.ErrVersionNotFound) {
+		return apierror.NewPolicyNotFoundError()
+	}
+	if errors.Is(err, registry.ErrInvalidPolicyID) {
+		return apierror.NewInvalidPolicyIDError()
+	}
+	if errors.Is(err, errAmbiguousPolicySource) {
+		return apierror.NewInvalidRequestBodyError()
+	}
+	return apierror.NewInternalError()
+}
+
 func errorFromBindJSON(err error) apierror.APIError {
 	return apierror.NewInvalidRequestBodyError()
 }
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -9,6 +11,7 @@ import (
 
 	"policy-inference-decider/internal/apierror"
 	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
 )
 
 func TestErrorFromPolicy(t *testing.T) {
@@ -17,7 +20,7 @@ func TestErrorFromPolicy(t *testing.T) {
 		inputErr := policy.ErrNoStartNode
 
 		// Act
-		got := errorFromPolicy(inputErr)
+		got := ErrorFromPolicy(inputErr)
 
 		// Assert
 		assert.Equal(t, http.StatusBadRequest, got.Status)
@@ -29,19 +32,103 @@ func TestErrorFromPolicy(t *testing.T) {
 		inputErr := policy.ErrInvalidCondition
 
 		// Act
-		got := errorFromPolicy(inputErr)
+		got := ErrorFromPolicy(inputErr)
 
 		// Assert
 		assert.Equal(t, http.StatusBadRequest, got.Status)
 		assert.Equal(t, apierror.CodeInvalidCondition, got.ErrorCode)
 		assert.Equal(t, "Invalid condition in policy.", got.Message)
 	})
+	t.Run("error ConditionError then returns 400 with the condition detail", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.ConditionError{Cond: "a <= b", Pos: 5, Msg: "variable b is not set"}
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, got.Status)
+		assert.Equal(t, apierror.CodeInvalidCondition, got.ErrorCode)
+		assert.Equal(t, `Invalid condition in policy: "a <= b": variable b is not set at column 6.`, got.Message)
+	})
+	t.Run("error ConditionError with ErrDivisionByZero then returns 422 and arithmetic_error", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.ConditionError{Cond: "debt / income < 0.4", Pos: 5, Msg: "division by zero in 100 / 0", Err: policy.ErrDivisionByZero}
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+		assert.Equal(t, apierror.CodeArithmeticError, got.ErrorCode)
+		assert.Equal(t, `Arithmetic error evaluating policy: "debt / income < 0.4": division by zero in 100 / 0 at column 6.`, got.Message)
+	})
+	t.Run("error ResultError then returns 400 and invalid_result with detail", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.ResultError{Result: "ok=true, name.first=Ana", Pos: 9, Msg: "name.first crosses a value that is not an object"}
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, got.Status)
+		assert.Equal(t, apierror.CodeInvalidResult, got.ErrorCode)
+		assert.Equal(t, `Invalid result in policy: "ok=true, name.first=Ana": name.first crosses a value that is not an object at column 10.`, got.Message)
+	})
+	t.Run("error computing a result value with ErrDivisionByZero then returns 422 and arithmetic_error", func(t *testing.T) {
+		// Arrange
+		_, inputErr := policy.ApplyResult("ratio=score/0", map[string]any{"score": 10})
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+		assert.Equal(t, apierror.CodeArithmeticError, got.ErrorCode)
+		assert.Equal(t, `Arithmetic error evaluating policy: "ratio=score/0": ratio: division by zero in 10 / 0 at column 12.`, got.Message)
+	})
+	t.Run("error CycleError then returns 422 and cycle_detected with path", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.CycleError{Path: []string{"a", "b", "a"}}
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+		assert.Equal(t, apierror.CodeCycleDetected, got.ErrorCode)
+		assert.Equal(t, "Cycle detected in policy graph: a -> b -> a.", got.Message)
+	})
+	t.Run("error ErrStepBudgetExceeded then returns 422 and step_budget_exceeded", func(t *testing.T) {
+		// Arrange
+		inputErr := fmt.Errorf("%w: 10 steps", policy.ErrStepBudgetExceeded)
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+		assert.Equal(t, apierror.CodeStepBudgetExceeded, got.ErrorCode)
+		assert.Equal(t, "Policy execution exceeded its step budget.", got.Message)
+	})
+	t.Run("error ErrTimeout then returns 504 and timeout", func(t *testing.T) {
+		// Arrange
+		inputErr := fmt.Errorf("%w: %w", policy.ErrTimeout, context.DeadlineExceeded)
+
+		// Act
+		got := ErrorFromPolicy(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusGatewayTimeout, got.Status)
+		assert.Equal(t, apierror.CodeTimeout, got.ErrorCode)
+		assert.Equal(t, "Policy evaluation timed out.", got.Message)
+	})
 	t.Run("error returns 500 and internal_error", func(t *testing.T) {
 		// Arrange
 		inputErr := errors.New("some execution error")
 
 		// Act
-		got := errorFromPolicy(inputErr)
+		got := ErrorFromPolicy(inputErr)
 
 		// Assert
 		assert.Equal(t, http.StatusInternalServerError, got.Status)
@@ -56,19 +143,54 @@ func TestErrorFromParseDOT(t *testing.T) {
 		inputErr := policy.ErrNoStartNode
 
 		// Act
-		got := errorFromParseDOT(inputErr)
+		got := ErrorFromParseDOT(inputErr)
 
 		// Assert
 		assert.Equal(t, http.StatusBadRequest, got.Status)
 		assert.Equal(t, apierror.CodePolicyNoStartNode, got.ErrorCode)
 		assert.Equal(t, "Policy graph has no start node.", got.Message)
 	})
+	t.Run("when ConditionError then returns 400 and invalid_condition with detail", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.ConditionError{Cond: "isAdult(age)", Pos: 0, Msg: "unknown function isAdult"}
+
+		// Act
+		got := ErrorFromParseDOT(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, got.Status)
+		assert.Equal(t, apierror.CodeInvalidCondition, got.ErrorCode)
+		assert.Equal(t, `Invalid condition in policy: "isAdult(age)": unknown function isAdult at column 1.`, got.Message)
+	})
+	t.Run("when ResultError then returns 400 and invalid_result with detail", func(t *testing.T) {
+		// Arrange
+		inputErr := &policy.ResultError{Result: `reason="age, score`, Pos: 7, Msg: "unterminated string"}
+
+		// Act
+		got := ErrorFromParseDOT(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, got.Status)
+		assert.Equal(t, apierror.CodeInvalidResult, got.ErrorCode)
+		assert.Equal(t, `Invalid result in policy: "reason=\"age, score": unterminated string at column 8.`, got.Message)
+	})
+	t.Run("when ErrTimeout then returns 504 and timeout", func(t *testing.T) {
+		// Arrange
+		inputErr := fmt.Errorf("%w: %w", policy.ErrTimeout, context.Canceled)
+
+		// Act
+		got := ErrorFromParseDOT(inputErr)
+
+		// Assert
+		assert.Equal(t, http.StatusGatewayTimeout, got.Status)
+		assert.Equal(t, apierror.CodeTimeout, got.ErrorCode)
+	})
 	t.Run("when other error then returns 400 and invalid_policy_dot", func(t *testing.T) {
 		// Arrange
 		inputErr := errors.New("syntax error: unexpected SEMI")
 
 		// Act
-		got := errorFromParseDOT(inputErr)
+		got := ErrorFromParseDOT(inputErr)
 
 		// Assert
 		assert.Equal(t, http.StatusBadRequest, got.Status)
@@ -76,3 +198,22 @@ func TestErrorFromParseDOT(t *testing.T) {
 		assert.Equal(t, "Invalid policy DOT format.", got.Message)
 	})
 }
+
+func TestErrorFromStore(t *testing.T) {
+	t.Run("when ErrVersionNotFound then returns 404 and policy_not_found", func(t *testing.T) {
+		// Act
+		got := errorFromStore(registry.ErrVersionNotFound)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, got.Status)
+		assert.Equal(t, apierror.CodePolicyNotFound, got.ErrorCode)
+	})
+	t.Run("when other error then returns 500 and internal_error", func(t *testing.T) {
+		// Act
+		got := errorFromStore(errors.New("disk full"))
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, got.Status)
+		assert.Equal(t, apierror.CodeInternalError, got.ErrorCode)
+	})
+}
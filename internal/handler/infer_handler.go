@@ -7,35 +7,50 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 
 	"policy-inference-decider/internal/apierror"
 	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
 )
 
+// responseDeadlineMargin is reserved before the invocation deadline so a timed-out
+// evaluation can still be answered with a 504 before Lambda kills the function.
+const responseDeadlineMargin = 200 * time.Millisecond
+
 type Handler struct {
-	parser   policy.Parser
-	executor policy.Executor
+	parser    policy.Parser
+	executor  policy.Executor
+	validator policy.Validator
+	store     registry.PolicyStore
 }
 
-func NewInferHandler(parser policy.Parser, executor policy.Executor) *Handler {
-	return &Handler{parser: parser, executor: executor}
+func NewInferHandler(parser policy.Parser, executor policy.Executor, validator policy.Validator, store registry.PolicyStore) *Handler {
+	return &Handler{parser: parser, executor: executor, validator: validator, store: store}
 }
 
 func (h *Handler) Infer(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	ctx, cancel := withResponseDeadline(ctx)
+	defer cancel()
 	switch req.RequestContext.HTTP.Method {
 	case http.MethodGet:
-		return h.handleGet(req), nil
+		return h.handleGet(ctx, req), nil
 	case http.MethodPost:
 		return h.handlePost(ctx, req), nil
+	case http.MethodPut:
+		return h.handlePut(ctx, req), nil
 	default:
 		return jsonErrorResponseURL(apierror.NewMethodNotAllowedError()), nil
 	}
 }
 
-func (h *Handler) handleGet(req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+func (h *Handler) handleGet(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
 	path := strings.TrimSuffix(pathFromRequest(req), "/")
+	if id, action, ok := policyRoute(path); ok && action == policyActionVersions {
+		return h.listPolicyVersions(ctx, req, id)
+	}
 	switch path {
 	case "/ping":
 		return events.LambdaFunctionURLResponse{
@@ -43,7 +58,7 @@ func (h *Handler) handleGet(req events.LambdaFunctionURLRequest) events.LambdaFu
 			Headers:    map[string]string{"Content-Type": "text/plain"},
 			Body:       "pong",
 		}
-	case "/infer":
+	case "/infer", "/infer/batch", "/validate":
 		return jsonErrorResponseURL(apierror.NewMethodNotAllowedError())
 	default:
 		return jsonErrorResponseURL(apierror.NewNotFoundError())
@@ -52,10 +67,35 @@ func (h *Handler) handleGet(req events.LambdaFunctionURLRequest) events.LambdaFu
 
 func (h *Handler) handlePost(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
 	path := strings.TrimSuffix(pathFromRequest(req), "/")
-	if path != "/infer" {
-		return jsonErrorResponseURL(apierror.NewNotFoundError())
+	if path == "/infer" {
+		return h.infer(ctx, req, "")
+	}
+	if path == "/infer/batch" {
+		return h.inferBatch(ctx, req)
+	}
+	if path == "/validate" {
+		return h.validate(ctx, req)
+	}
+	if id, action, ok := policyRoute(path); ok && action == policyActionInfer {
+		return h.infer(ctx, req, id)
+	}
+	return jsonErrorResponseURL(apierror.NewNotFoundError())
+}
+
+func (h *Handler) handlePut(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+	path := strings.TrimSuffix(pathFromRequest(req), "/")
+	if id, action, ok := policyRoute(path); ok && action == "" {
+		return h.putPolicy(ctx, req, id)
 	}
-	return h.infer(ctx, req)
+	return jsonErrorResponseURL(apierror.NewMethodNotAllowedError())
+}
+
+func withResponseDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-responseDeadlineMargin))
 }
 
 func pathFromRequest(req events.LambdaFunctionURLRequest) string {
@@ -65,29 +105,35 @@ func pathFromRequest(req events.LambdaFunctionURLRequest) string {
 	return req.RawPath
 }
 
-func (h *Handler) infer(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+// infer evaluates the request body. policyID comes from the /policies/{id}/infer route and,
+// when set, takes the place of policy_id in the body.
+func (h *Handler) infer(ctx context.Context, req events.LambdaFunctionURLRequest, policyID string) events.LambdaFunctionURLResponse {
 	var body policy.InferRequest
 	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
 		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference] [msg:bind_json] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
 		return HandleURL(err, errorFromBindJSON)
 	}
+	if policyID != "" {
+		body.PolicyID = policyID
+	}
 
-	graph, err := h.parser.Parse(ctx, body.PolicyDOT)
+	dot, err := h.resolvePolicyDOT(ctx, body.PolicyDOT, body.PolicyID, body.Version)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference] [msg:resolve_policy] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromStore)
+	}
+
+	graph, err := h.parser.Parse(ctx, dot)
 	if err != nil {
 		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference] [msg:parse_dot] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
-		return HandleURL(err, errorFromParseDOT)
+		return HandleURL(err, ErrorFromParseDOT)
 	}
 
-	resp, err := h.executor.Process(ctx, graph, body.Input)
+	resp, err := h.executor.Process(ctx, graph, body.Input, body.Options())
 	if err != nil {
 		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_inference] [msg:execute] [request_id:%s] [err:%+v] ", req.RequestContext.RequestID, err))
-		return HandleURL(err, errorFromPolicy)
+		return HandleURL(err, ErrorFromPolicy)
 	}
 
-	responseBody, _ := json.Marshal(resp)
-	return events.LambdaFunctionURLResponse{
-		StatusCode: http.StatusOK,
-		Headers:    map[string]string{"Content-Type": "application/json"},
-		Body:       string(responseBody),
-	}
+	return jsonResponseURL(http.StatusOK, resp)
 }
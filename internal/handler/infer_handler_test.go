@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 
 	"policy-inference-decider/internal/apierror"
 	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
 )
 
 const exampleDOT = `digraph { start [result=""]; ok [result="approved=true"]; no [result="approved=false"]; start -> ok [cond="age>=18"]; start -> no [cond="age<18"]; }`
@@ -65,7 +67,7 @@ func bodyFromInferRequest(r policy.InferRequest) string {
 func TestInfer(t *testing.T) {
 	t.Run("success - approved true when age >= 18", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -81,7 +83,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("success - approved false when age < 18", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 15}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -95,9 +97,46 @@ func TestInfer(t *testing.T) {
 		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
 		assert.Equal(t, inferResponseBody{Output: map[string]any{"age": float64(15), "approved": false}}, out)
 	})
+	t.Run("success - explain returns trace of visited nodes", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}, Explain: true})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out policy.InferResponse
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		require.Len(t, out.Trace, 2)
+		assert.Equal(t, "start", out.Trace[0].Node)
+		assert.Equal(t, []policy.EdgeEvaluation{{To: "ok", Cond: "age>=18", Result: true}}, out.Trace[0].Edges)
+		assert.Equal(t, "ok", out.Trace[1].Node)
+		assert.Equal(t, map[string]any{"approved": true}, out.Trace[1].Assigned)
+	})
+	t.Run("success - response carries terminal node and termination reason", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: policyChallengeDOT, Input: map[string]any{"age": 30, "score": 600}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out policy.InferResponse
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		assert.Equal(t, "review", out.Node)
+		assert.Equal(t, policy.TerminationNoMatchingEdge, out.Termination)
+	})
 	t.Run("bad request - invalid JSON body returns APIError format", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		req := makeURLRequest("invalid", http.MethodPost, "/infer")
 
 		// Act
@@ -114,7 +153,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("bad request - DOT without start node returns policy_no_start_node", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dotNoStart, Input: map[string]any{"x": 1}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -130,7 +169,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("success - graph with cycle terminates and returns output", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dotWithCycle, Input: map[string]any{"x": 1}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -144,9 +183,59 @@ func TestInfer(t *testing.T) {
 		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
 		assert.Equal(t, inferResponseBody{Output: map[string]any{"x": float64(1), "done": true}}, out)
 	})
+	t.Run("unprocessable - strict graph with cycle returns cycle_detected", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dotWithCycle, Input: map[string]any{"x": 1}, Strict: true})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeCycleDetected, apiErr.Error)
+		assert.Equal(t, "Cycle detected in policy graph: a -> a.", apiErr.Message)
+	})
+	t.Run("gateway timeout - deadline inside response margin returns timeout", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+		ctx, cancel := context.WithTimeout(context.Background(), responseDeadlineMargin/2)
+		defer cancel()
+
+		// Act
+		resp, err := h.Infer(ctx, req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeTimeout, apiErr.Error)
+	})
+	t.Run("success - deadline beyond response margin still evaluates", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+		ctx, cancel := context.WithTimeout(context.Background(), responseDeadlineMargin+10*time.Second)
+		defer cancel()
+
+		// Act
+		resp, err := h.Infer(ctx, req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
 	t.Run("bad request - invalid condition in edge returns invalid_condition", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dotWithInvalidCond, Input: map[string]any{"x": 1}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -160,9 +249,27 @@ func TestInfer(t *testing.T) {
 		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
 		assert.Equal(t, apierror.CodeInvalidCondition, apiErr.Error)
 	})
+	t.Run("bad request - missing operand is named in the message", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		dot := `digraph { start [result=""]; ok [result="approved=true"]; start -> ok [cond="requested_amount <= credit_limit"]; }`
+		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dot, Input: map[string]any{"requested_amount": 500}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeInvalidCondition, apiErr.Error)
+		assert.Contains(t, apiErr.Message, "variable credit_limit is not set")
+	})
 	t.Run("bad request - invalid DOT format returns invalid_policy_dot", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: dothWithInvalidFormat, Input: map[string]any{"age": 25}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -178,7 +285,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("challenge example - Policy graph with age 25 score 720 returns approved and segment prime", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: policyChallengeDOT, Input: map[string]any{"age": 25, "score": 720}})
 		req := makeURLRequest(body, http.MethodPost, "/infer")
 
@@ -194,7 +301,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("not found when path is not /infer", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		req := makeURLRequest("", http.MethodPost, "/other")
 
 		// Act
@@ -209,7 +316,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("method not allowed when not POST", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}})
 		req := makeURLRequest(body, http.MethodGet, "/infer")
 
@@ -225,7 +332,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("GET /ping returns pong", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		req := makeURLRequest("", http.MethodGet, "/ping")
 
 		// Act
@@ -238,7 +345,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("unsupported method returns 405", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		body := bodyFromInferRequest(policy.InferRequest{PolicyDOT: exampleDOT, Input: map[string]any{"age": 20}})
 		req := makeURLRequest(body, http.MethodPut, "/infer")
 
@@ -254,7 +361,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("GET other path returns 404", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		req := makeURLRequest("", http.MethodGet, "/other")
 
 		// Act
@@ -269,7 +376,7 @@ func TestInfer(t *testing.T) {
 	})
 	t.Run("pathFromRequest uses RawPath when HTTP.Path empty", func(t *testing.T) {
 		// Arrange
-		h := NewInferHandler(policy.NewDotParser(), policy.NewGraphExecutor())
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
 		req := makeURLRequestWithRawPath("", http.MethodGet, "/ping")
 
 		// Act
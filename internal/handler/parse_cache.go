@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"policy-inference-decider/internal/policy"
+)
+
+const (
+	envParseCacheSize = "POLICY_CACHE_SIZE"
+	envParseCacheTTL  = "POLICY_CACHE_TTL"
+
+	defaultParseCacheSize = 128
+	defaultParseCacheTTL  = 10 * time.Minute
+)
+
+// CachedParser is a bounded LRU in front of a policy.Parser, keyed by the SHA-256 of the
+// normalized DOT text. It is safe for concurrent use and lives for the whole process, so
+// warm Lambda invocations reuse graphs (and their compiled form) parsed by earlier ones.
+type CachedParser struct {
+	parser  policy.Parser
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	hits    uint64
+	misses  uint64
+}
+
+type parseCacheEntry struct {
+	key     string
+	graph   *policy.Graph
+	expires time.Time
+}
+
+// NewCachedParser wraps parser with an LRU of at most size graphs; size <= 0 disables caching
+// and ttl <= 0 keeps entries until they are evicted.
+func NewCachedParser(parser policy.Parser, size int, ttl time.Duration) *CachedParser {
+	return &CachedParser{
+		parser:  parser,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// ParseCacheConfigFromEnv reads POLICY_CACHE_SIZE (entries) and POLICY_CACHE_TTL (Go duration),
+// falling back to the defaults when a variable is unset or malformed.
+func ParseCacheConfigFromEnv() (size int, ttl time.Duration) {
+	size, ttl = defaultParseCacheSize, defaultParseCacheTTL
+	if v, err := strconv.Atoi(os.Getenv(envParseCacheSize)); err == nil {
+		size = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(envParseCacheTTL)); err == nil {
+		ttl = v
+	}
+	return size, ttl
+}
+
+func (c *CachedParser) Parse(ctx context.Context, dot string) (*policy.Graph, error) {
+	if c.size <= 0 {
+		return c.parser.Parse(ctx, dot)
+	}
+	key := parseCacheKey(dot)
+	if graph, ok := c.get(key); ok {
+		c.logLookup(ctx, "hit")
+		return graph, nil
+	}
+	c.logLookup(ctx, "miss")
+	graph, err := c.parser.Parse(ctx, dot)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, graph)
+	return graph, nil
+}
+
+// Stats returns the cumulative hit and miss counters.
+func (c *CachedParser) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *CachedParser) get(key string) (*policy.Graph, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if ok {
+		entry := elem.Value.(*parseCacheEntry)
+		if c.ttl <= 0 || c.now().Before(entry.expires) {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return entry.graph, true
+		}
+		c.remove(elem)
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *CachedParser) put(key string, graph *policy.Graph) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &parseCacheEntry{key: key, graph: graph, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *CachedParser) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*parseCacheEntry).key)
+}
+
+func (c *CachedParser) logLookup(ctx context.Context, result string) {
+	hits, misses := c.Stats()
+	slog.InfoContext(ctx, fmt.Sprintf("[feature:policy_inference] [msg:parse_cache] [result:%s] [hits:%d] [misses:%d]", result, hits, misses))
+}
+
+func parseCacheKey(dot string) string {
+	sum := sha256.Sum256([]byte(normalizeDOT(dot)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeDOT collapses each run of whitespace between tokens into a single newline when the
+// run contains one and into a single space otherwise, so policies that differ only in
+// indentation and spacing share a cache entry. Line breaks are kept because they end // and
+// # comments, and quoted strings, HTML strings and comments are copied verbatim, so two texts
+// with the same key always tokenize the same way.
+func normalizeDOT(dot string) string {
+	var b strings.Builder
+	b.Grow(len(dot))
+	for i := 0; i < len(dot); {
+		switch c := dot[i]; {
+		case isDOTSpace(c):
+			start := i
+			for i < len(dot) && isDOTSpace(dot[i]) {
+				i++
+			}
+			if strings.ContainsAny(dot[start:i], "\n\r") {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(' ')
+			}
+			continue
+		case c == '"':
+			i = copyQuotedDOT(&b, dot, i)
+		case c == '<':
+			i = copyHTMLDOT(&b, dot, i)
+		case c == '#' || strings.HasPrefix(dot[i:], "//"):
+			end := strings.IndexAny(dot[i:], "\n\r")
+			if end < 0 {
+				end = len(dot) - i
+			}
+			b.WriteString(dot[i : i+end])
+			i += end
+		case strings.HasPrefix(dot[i:], "/*"):
+			end := strings.Index(dot[i+2:], "*/")
+			if end < 0 {
+				end = len(dot) - i - 2
+			} else {
+				end += 2
+			}
+			b.WriteString(dot[i : i+2+end])
+			i += 2 + end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// copyQuotedDOT writes the quoted string starting at start and returns the offset past it.
+func copyQuotedDOT(b *strings.Builder, dot string, start int) int {
+	i := start + 1
+	for i < len(dot) && dot[i] != '"' {
+		if dot[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	end := min(i+1, len(dot))
+	b.WriteString(dot[start:end])
+	return end
+}
+
+// copyHTMLDOT writes the HTML string starting at start, whose angle brackets nest, and returns
+// the offset past it.
+func copyHTMLDOT(b *strings.Builder, dot string, start int) int {
+	depth, i := 0, start
+	for ; i < len(dot); i++ {
+		if dot[i] == '<' {
+			depth++
+		} else if dot[i] == '>' {
+			depth--
+			if depth == 0 {
+				i++
+				break
+			}
+		}
+	}
+	b.WriteString(dot[start:i])
+	return i
+}
+
+func isDOTSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
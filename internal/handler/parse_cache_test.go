@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/policy"
+)
+
+type countingParser struct {
+	calls int
+}
+
+func (p *countingParser) Parse(ctx context.Context, dot string) (*policy.Graph, error) {
+	p.calls++
+	return policy.NewDotParser(nil).Parse(ctx, dot)
+}
+
+func TestCachedParser(t *testing.T) {
+	t.Run("second parse of same policy is a hit", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+
+		// Act
+		first, err := parser.Parse(context.Background(), exampleDOT)
+		require.NoError(t, err)
+		second, err := parser.Parse(context.Background(), exampleDOT)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Same(t, first, second)
+		assert.Equal(t, 1, inner.calls)
+		hits, misses := parser.Stats()
+		assert.Equal(t, uint64(1), hits)
+		assert.Equal(t, uint64(1), misses)
+	})
+	t.Run("indentation and spacing outside quotes share an entry", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+		dot := "digraph {\n  start [result=\"\"];\n  ok [result=\"approved=true\"];\n  start -> ok [cond=\"age>=18\"];\n}"
+		reformatted := "digraph   {\r\n\n\tstart  [result=\"\"];  \n\tok\t[result=\"approved=true\"];\n\tstart ->  ok [cond=\"age>=18\"];\n}"
+
+		// Act
+		_, err := parser.Parse(context.Background(), dot)
+		require.NoError(t, err)
+		_, err = parser.Parse(context.Background(), reformatted)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 1, inner.calls)
+	})
+	t.Run("line breaks ending comments are significant", func(t *testing.T) {
+		// Arrange
+		parser := NewCachedParser(policy.NewDotParser(nil), 4, time.Minute)
+		multiline := "digraph {\nstart [result=\"x=1\"] // note\nstart -> b [cond=\"x==1\"]; b [result=\"y=2\"]; }"
+		oneLine := "digraph { start [result=\"x=1\"] // note start -> b [cond=\"x==1\"]; b [result=\"y=2\"]; }"
+
+		// Act
+		_, err := parser.Parse(context.Background(), multiline)
+		require.NoError(t, err)
+		_, err = parser.Parse(context.Background(), oneLine)
+
+		// Assert
+		assert.ErrorIs(t, err, policy.ErrInvalidPolicyDot)
+	})
+	t.Run("quotes inside comments do not hide whitespace in later strings", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+
+		// Act
+		_, err := parser.Parse(context.Background(), "digraph { # say \"hi\n start [result=\"a=x y\"]; }")
+		require.NoError(t, err)
+		_, err = parser.Parse(context.Background(), "digraph { # say \"hi\n start [result=\"a=x  y\"]; }")
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 2, inner.calls)
+	})
+	t.Run("whitespace inside quotes is significant", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+
+		// Act
+		_, err := parser.Parse(context.Background(), `digraph { start [result="a=x y"]; }`)
+		require.NoError(t, err)
+		_, err = parser.Parse(context.Background(), `digraph { start [result="a=x  y"]; }`)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 2, inner.calls)
+	})
+	t.Run("whitespace inside HTML strings and block comments is kept", func(t *testing.T) {
+		// Arrange
+		keys := []string{
+			parseCacheKey("digraph { start [label=<a  <b>x</b>>]; }"),
+			parseCacheKey("digraph { start [label=<a <b>x</b>>]; }"),
+			parseCacheKey("digraph { /* a  b */ start; }"),
+			parseCacheKey("digraph { /* a b */ start; }"),
+		}
+
+		// Assert
+		assert.NotEqual(t, keys[0], keys[1])
+		assert.NotEqual(t, keys[2], keys[3])
+		assert.Equal(t, parseCacheKey("digraph {  /* a */\tstart; }"), parseCacheKey("digraph { /* a */ start; }"))
+	})
+	t.Run("least recently used entry is evicted", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 1, time.Minute)
+
+		// Act
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+		_, _ = parser.Parse(context.Background(), policyChallengeDOT)
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+
+		// Assert
+		assert.Equal(t, 3, inner.calls)
+	})
+	t.Run("expired entry is parsed again", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+		now := time.Now()
+		parser.now = func() time.Time { return now }
+
+		// Act
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+		now = now.Add(2 * time.Minute)
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+
+		// Assert
+		assert.Equal(t, 2, inner.calls)
+	})
+	t.Run("parse errors are not cached", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 4, time.Minute)
+
+		// Act
+		_, firstErr := parser.Parse(context.Background(), dotNoStart)
+		_, secondErr := parser.Parse(context.Background(), dotNoStart)
+
+		// Assert
+		assert.ErrorIs(t, firstErr, policy.ErrNoStartNode)
+		assert.ErrorIs(t, secondErr, policy.ErrNoStartNode)
+		assert.Equal(t, 2, inner.calls)
+	})
+	t.Run("size zero disables caching", func(t *testing.T) {
+		// Arrange
+		inner := &countingParser{}
+		parser := NewCachedParser(inner, 0, time.Minute)
+
+		// Act
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+		_, _ = parser.Parse(context.Background(), exampleDOT)
+
+		// Assert
+		assert.Equal(t, 2, inner.calls)
+	})
+}
+
+func TestParseCacheConfigFromEnv(t *testing.T) {
+	t.Run("reads size and ttl from environment", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envParseCacheSize, "16")
+		t.Setenv(envParseCacheTTL, "30s")
+
+		// Act
+		size, ttl := ParseCacheConfigFromEnv()
+
+		// Assert
+		assert.Equal(t, 16, size)
+		assert.Equal(t, 30*time.Second, ttl)
+	})
+	t.Run("falls back to defaults when unset or malformed", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envParseCacheSize, "")
+		t.Setenv(envParseCacheTTL, "soon")
+
+		// Act
+		size, ttl := ParseCacheConfigFromEnv()
+
+		// Assert
+		assert.Equal(t, defaultParseCacheSize, size)
+		assert.Equal(t, defaultParseCacheTTL, ttl)
+	})
+}
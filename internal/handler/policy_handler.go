@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"policy-inference-decider/internal/registry"
+)
+
+const (
+	policiesPathPrefix   = "/policies/"
+	policyActionInfer    = "infer"
+	policyActionVersions = "versions"
+)
+
+var errAmbiguousPolicySource = errors.New("request sets both policy_dot and policy_id")
+
+type (
+	putPolicyRequest struct {
+		PolicyDOT string `json:"policy_dot"`
+	}
+
+	policyVersionsResponse struct {
+		ID       string                   `json:"id"`
+		Versions []registry.PolicyVersion `json:"versions"`
+	}
+)
+
+// policyRoute splits /policies/{id} and /policies/{id}/{action} paths.
+func policyRoute(path string) (id, action string, ok bool) {
+	rest, found := strings.CutPrefix(path, policiesPathPrefix)
+	if !found || rest == "" {
+		return "", "", false
+	}
+	id, action, _ = strings.Cut(rest, "/")
+	if id == "" || strings.Contains(action, "/") {
+		return "", "", false
+	}
+	return id, action, true
+}
+
+// resolvePolicyDOT returns the inline DOT of a request or loads the referenced version from the store.
+func (h *Handler) resolvePolicyDOT(ctx context.Context, dot, policyID string, version int) (string, error) {
+	if policyID == "" {
+		return dot, nil
+	}
+	if dot != "" {
+		return "", errAmbiguousPolicySource
+	}
+	stored, err := h.store.Get(ctx, policyID, version)
+	if err != nil {
+		return "", err
+	}
+	return stored.DOT, nil
+}
+
+func (h *Handler) putPolicy(ctx context.Context, req events.LambdaFunctionURLRequest, id string) events.LambdaFunctionURLResponse {
+	if err := registry.ValidateID(id); err != nil {
+		return HandleURL(err, errorFromStore)
+	}
+	var body putPolicyRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_registry] [msg:bind_json] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromBindJSON)
+	}
+	if _, err := h.parser.Parse(ctx, body.PolicyDOT); err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_registry] [msg:parse_dot] [request_id: %s] [policy_id:%s] [err:%+v]", req.RequestContext.RequestID, id, err))
+		return HandleURL(err, ErrorFromParseDOT)
+	}
+	stored, err := h.store.Put(ctx, id, body.PolicyDOT)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_registry] [msg:store_put] [request_id: %s] [policy_id:%s] [err:%+v]", req.RequestContext.RequestID, id, err))
+		return HandleURL(err, errorFromStore)
+	}
+	stored.DOT = ""
+	return jsonResponseURL(http.StatusCreated, stored)
+}
+
+func (h *Handler) listPolicyVersions(ctx context.Context, req events.LambdaFunctionURLRequest, id string) events.LambdaFunctionURLResponse {
+	versions, err := h.store.Versions(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_registry] [msg:store_versions] [request_id: %s] [policy_id:%s] [err:%+v]", req.RequestContext.RequestID, id, err))
+		return HandleURL(err, errorFromStore)
+	}
+	return jsonResponseURL(http.StatusOK, policyVersionsResponse{ID: id, Versions: versions})
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
+)
+
+func bodyFromPutPolicy(dot string) string {
+	b, _ := json.Marshal(putPolicyRequest{PolicyDOT: dot})
+	return string(b)
+}
+
+func newRegistryHandler(t *testing.T, policies ...string) *Handler {
+	t.Helper()
+	store := registry.NewMemoryStore()
+	for _, dot := range policies {
+		_, err := store.Put(context.Background(), "credit", dot)
+		require.NoError(t, err)
+	}
+	return NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), store)
+}
+
+func TestPolicyRegistryRoutes(t *testing.T) {
+	t.Run("PUT /policies/{id} stores a new version", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT)
+		req := makeURLRequest(bodyFromPutPolicy(policyChallengeDOT), http.MethodPut, "/policies/credit")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var got registry.PolicyVersion
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &got))
+		assert.Equal(t, "credit", got.ID)
+		assert.Equal(t, 2, got.Version)
+		assert.Empty(t, got.DOT)
+	})
+	t.Run("PUT /policies/{id} rejects invalid DOT", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t)
+		req := makeURLRequest(bodyFromPutPolicy(dotNoStart), http.MethodPut, "/policies/credit")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodePolicyNoStartNode, apiErr.Error)
+	})
+	t.Run("PUT /policies/{id} rejects calls with the wrong arity", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t)
+		dot := `digraph { start [result=""]; a [result=""]; start -> a [cond="len(a, b) > 1"]; }`
+		req := makeURLRequest(bodyFromPutPolicy(dot), http.MethodPut, "/policies/credit")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeInvalidCondition, apiErr.Error)
+		assert.Contains(t, apiErr.Message, "len takes 1 arguments, got 2")
+	})
+	t.Run("PUT /policies/{id} rejects invalid JSON", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t)
+		req := makeURLRequest("invalid", http.MethodPut, "/policies/credit")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("PUT /policies/{id} rejects invalid id", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t)
+		req := makeURLRequest(bodyFromPutPolicy(exampleDOT), http.MethodPut, "/policies/-bad")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeInvalidPolicyID, apiErr.Error)
+	})
+	t.Run("GET /policies/{id}/versions lists versions", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT, policyChallengeDOT)
+		req := makeURLRequest("", http.MethodGet, "/policies/credit/versions")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var got policyVersionsResponse
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &got))
+		assert.Equal(t, "credit", got.ID)
+		require.Len(t, got.Versions, 2)
+		assert.Equal(t, 2, got.Versions[1].Version)
+	})
+	t.Run("GET /policies/{id}/versions of unknown policy returns policy_not_found", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t)
+		req := makeURLRequest("", http.MethodGet, "/policies/ghost/versions")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodePolicyNotFound, apiErr.Error)
+	})
+	t.Run("POST /policies/{id}/infer uses latest version", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT, policyChallengeDOT)
+		body := bodyFromInferRequest(policy.InferRequest{Input: map[string]any{"age": 25, "score": 720}})
+		req := makeURLRequest(body, http.MethodPost, "/policies/credit/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out inferResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		assert.Equal(t, "prime", out.Output["segment"])
+	})
+	t.Run("POST /policies/{id}/infer honors explicit version", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT, policyChallengeDOT)
+		body := bodyFromInferRequest(policy.InferRequest{Version: 1, Input: map[string]any{"age": 25, "score": 720}})
+		req := makeURLRequest(body, http.MethodPost, "/policies/credit/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out inferResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		assert.Equal(t, inferResponseBody{Output: map[string]any{"age": float64(25), "score": float64(720), "approved": true}}, out)
+	})
+	t.Run("POST /infer with policy_id resolves from the store", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT)
+		body := bodyFromInferRequest(policy.InferRequest{PolicyID: "credit", Input: map[string]any{"age": 15}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var out inferResponseBody
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &out))
+		assert.Equal(t, false, out.Output["approved"])
+	})
+	t.Run("POST /infer with unknown version returns policy_not_found", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT)
+		body := bodyFromInferRequest(policy.InferRequest{PolicyID: "credit", Version: 9, Input: map[string]any{"age": 15}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodePolicyNotFound, apiErr.Error)
+	})
+	t.Run("POST /infer with both policy_dot and policy_id is rejected", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT)
+		body := bodyFromInferRequest(policy.InferRequest{PolicyID: "credit", PolicyDOT: exampleDOT, Input: map[string]any{"age": 15}})
+		req := makeURLRequest(body, http.MethodPost, "/infer")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var apiErr APIError
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &apiErr))
+		assert.Equal(t, apierror.CodeInvalidRequestBody, apiErr.Error)
+	})
+	t.Run("unknown policy sub-route returns 404", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, exampleDOT)
+		req := makeURLRequest("", http.MethodPost, "/policies/credit/other")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestPolicyRoute(t *testing.T) {
+	t.Run("splits id and action", func(t *testing.T) {
+		// Act
+		id, action, ok := policyRoute("/policies/credit/infer")
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, "credit", id)
+		assert.Equal(t, "infer", action)
+	})
+	t.Run("rejects paths outside /policies and nested actions", func(t *testing.T) {
+		// Act
+		_, _, rootOK := policyRoute("/policies/")
+		_, _, otherOK := policyRoute("/infer")
+		_, _, nestedOK := policyRoute("/policies/credit/infer/extra")
+
+		// Assert
+		assert.False(t, rootOK)
+		assert.False(t, otherOK)
+		assert.False(t, nestedOK)
+	})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"policy-inference-decider/internal/policy"
+)
+
+// validate answers 200 with the validation report of the policy, valid or not; only a request
+// that cannot be validated at all (bad body, unknown policy, DOT syntax error) is an API error.
+func (h *Handler) validate(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+	var body policy.ValidateRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_validation] [msg:bind_json] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromBindJSON)
+	}
+
+	dot, err := h.resolvePolicyDOT(ctx, body.PolicyDOT, body.PolicyID, body.Version)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_validation] [msg:resolve_policy] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, errorFromStore)
+	}
+
+	report, err := h.validator.Validate(ctx, dot, body.Options())
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("[feature:policy_validation] [msg:validate] [request_id: %s] [err:%+v]", req.RequestContext.RequestID, err))
+		return HandleURL(err, ErrorFromParseDOT)
+	}
+	return jsonResponseURL(http.StatusOK, report)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
+)
+
+func bodyFromValidateRequest(r policy.ValidateRequest) string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid policy returns 200 with no findings", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		req := makeURLRequest(bodyFromValidateRequest(policy.ValidateRequest{PolicyDOT: exampleDOT}), http.MethodPost, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"valid": true, "findings": []}`, resp.Body)
+	})
+	t.Run("invalid policy returns 200 with every finding", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		dot := `digraph { start [result=""]; end [result="x=1"]; start -> end [cond="invalid!!!"]; start -> ghost; }`
+		req := makeURLRequest(bodyFromValidateRequest(policy.ValidateRequest{PolicyDOT: dot}), http.MethodPost, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var report policy.ValidationReport
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &report))
+		assert.False(t, report.Valid)
+		require.Len(t, report.Findings, 2)
+		assert.Equal(t, policy.FindingInvalidCond, report.Findings[0].Code)
+		assert.Equal(t, "end", report.Findings[0].To)
+		assert.Equal(t, policy.FindingUndeclaredNode, report.Findings[1].Code)
+	})
+	t.Run("strict reports overlapping conditions as errors", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [cond="age>=18"]; start -> b [cond="age>=21"]; start -> b [cond="age<18"]; }`
+		req := makeURLRequest(bodyFromValidateRequest(policy.ValidateRequest{PolicyDOT: dot, Strict: true}), http.MethodPost, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		var report policy.ValidationReport
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &report))
+		assert.False(t, report.Valid)
+		require.Len(t, report.Findings, 1)
+		assert.Equal(t, policy.FindingOverlappingConditions, report.Findings[0].Code)
+		assert.Equal(t, policy.SeverityError, report.Findings[0].Severity)
+	})
+	t.Run("policy_id validates the stored policy", func(t *testing.T) {
+		// Arrange
+		h := newRegistryHandler(t, dotNoStart)
+		req := makeURLRequest(bodyFromValidateRequest(policy.ValidateRequest{PolicyID: "credit"}), http.MethodPost, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		var report policy.ValidationReport
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &report))
+		assert.False(t, report.Valid)
+		assert.Equal(t, policy.FindingNoStartNode, report.Findings[0].Code)
+	})
+	t.Run("DOT syntax error returns 400 and invalid_policy_dot", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		req := makeURLRequest(bodyFromValidateRequest(policy.ValidateRequest{PolicyDOT: `digraph { start [result=]; }`}), http.MethodPost, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, resp.Body, apierror.CodeInvalidPolicyDOT)
+	})
+	t.Run("GET returns 405", func(t *testing.T) {
+		// Arrange
+		h := NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore())
+		req := makeURLRequest("", http.MethodGet, "/validate")
+
+		// Act
+		resp, err := h.Infer(context.Background(), req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// CompiledGraph is the execution form of a Graph: outgoing edges are indexed per node in
+// evaluation order and every condition is parsed once, so a step costs O(out-degree).
+type CompiledGraph struct {
+	graph     *Graph
+	functions *FunctionRegistry
+	outgoing  map[string][]compiledEdge
+	results   map[string]compiledResult
+}
+
+type compiledEdge struct {
+	edge *Edge
+	cond condition
+}
+
+// condition is a pre-parsed edge condition. A condition that fails to compile keeps its
+// error and reports it when evaluated, so an invalid edge only fails the paths that reach it.
+type condition struct {
+	src string
+	ast exprNode
+	err error
+}
+
+// Compile builds the CompiledGraph for calls resolved against functions (nil means the
+// built-ins) and caches it on the graph; later calls with the same registry return the cached value.
+func (g *Graph) Compile(functions *FunctionRegistry) *CompiledGraph {
+	functions = functions.orBuiltins()
+	g.compileMu.Lock()
+	defer g.compileMu.Unlock()
+	if g.compiled == nil || g.compiled.functions != functions {
+		g.compiled = compileGraph(g, functions)
+	}
+	return g.compiled
+}
+
+func compileGraph(graph *Graph, functions *FunctionRegistry) *CompiledGraph {
+	byNode := make(map[string][]*Edge)
+	for _, edge := range graph.Edges {
+		byNode[edge.From] = append(byNode[edge.From], edge)
+	}
+	outgoing := make(map[string][]compiledEdge, len(byNode))
+	for from, edges := range byNode {
+		for _, edge := range evaluationOrder(edges) {
+			outgoing[from] = append(outgoing[from], compiledEdge{edge: edge, cond: compileCondition(edge.Cond, functions)})
+		}
+	}
+	results := make(map[string]compiledResult, len(graph.Nodes))
+	for id, node := range graph.Nodes {
+		results[id] = compileResult(node.Result, functions)
+	}
+	keepCounters(slices.Collect(maps.Values(results)))
+	return &CompiledGraph{graph: graph, functions: functions, outgoing: outgoing, results: results}
+}
+
+// evaluationOrder returns the outgoing edges of one node in the order they are tried: higher
+// priority first, declaration order among equal priorities, and the default edge last.
+func evaluationOrder(edges []*Edge) []*Edge {
+	ordered := slices.Clone(edges)
+	slices.SortStableFunc(ordered, func(a, b *Edge) int {
+		if a.Default != b.Default {
+			if a.Default {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return ordered
+}
+
+func compileCondition(cond string, functions *FunctionRegistry) condition {
+	if cond == "" {
+		return condition{}
+	}
+	ast, err := parseCondition(cond)
+	if err != nil {
+		return condition{err: err}
+	}
+	if err := checkCondition(cond, ast, functions); err != nil {
+		return condition{err: err}
+	}
+	return condition{src: cond, ast: ast}
+}
+
+func (c condition) eval(vars map[string]any) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.ast == nil {
+		return true, nil
+	}
+	return evaluator{src: c.src, vars: vars}.evalBool(c.ast)
+}
+
+// apply runs the result of node current, if it is declared, against vars.
+func (c *CompiledGraph) apply(current string, vars map[string]any) (map[string]any, error) {
+	return c.results[current].apply(vars)
+}
+
+// next returns the first outgoing edge from current, in evaluation order, whose condition evaluates
+// to true (deterministic single path); the default edge, having no condition, matches when reached.
+// When explain is set it also returns every condition it evaluated, in order, with its outcome.
+func (c *CompiledGraph) next(current string, vars map[string]any, explain bool) (string, []EdgeEvaluation, error) {
+	var evals []EdgeEvaluation
+	for _, out := range c.outgoing[current] {
+		ok, err := out.cond.eval(vars)
+		if err != nil {
+			return "", evals, err
+		}
+		if explain {
+			evals = append(evals, EdgeEvaluation{To: out.edge.To, Cond: out.edge.Cond, Default: out.edge.Default, Result: ok})
+		}
+		if ok {
+			return out.edge.To, evals, nil
+		}
+	}
+	return "", evals, nil
+}
@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	t.Run("indexes outgoing edges per node in declaration order", func(t *testing.T) {
+		// Arrange
+		graph := &Graph{Start: StartNodeID, Edges: []*Edge{
+			{From: "start", To: "a", Cond: "x==1"},
+			{From: "a", To: "b"},
+			{From: "start", To: "b", Cond: "x==2"},
+		}}
+
+		// Act
+		compiled := graph.Compile(nil)
+
+		// Assert
+		require.Len(t, compiled.outgoing["start"], 2)
+		assert.Equal(t, "a", compiled.outgoing["start"][0].edge.To)
+		assert.Equal(t, "b", compiled.outgoing["start"][1].edge.To)
+		assert.Len(t, compiled.outgoing["a"], 1)
+	})
+	t.Run("orders outgoing edges by priority with the default edge last", func(t *testing.T) {
+		// Arrange
+		graph := &Graph{Start: StartNodeID, Edges: []*Edge{
+			{From: "start", To: "fallback", Default: true},
+			{From: "start", To: "low", Cond: "x==1"},
+			{From: "start", To: "high", Cond: "x==2", Priority: 10},
+			{From: "start", To: "low2", Cond: "x==3"},
+		}}
+
+		// Act
+		compiled := graph.Compile(nil)
+
+		// Assert
+		var order []string
+		for _, out := range compiled.outgoing["start"] {
+			order = append(order, out.edge.To)
+		}
+		assert.Equal(t, []string{"high", "low", "low2", "fallback"}, order)
+	})
+	t.Run("compiles once and caches on the graph", func(t *testing.T) {
+		// Arrange
+		graph := &Graph{Start: StartNodeID}
+
+		// Act
+		first := graph.Compile(nil)
+		second := graph.Compile(nil)
+
+		// Assert
+		assert.Same(t, first, second)
+	})
+	t.Run("invalid condition fails only when evaluated", func(t *testing.T) {
+		// Arrange
+		graph := &Graph{Start: StartNodeID, Edges: []*Edge{
+			{From: "start", To: "ok", Cond: "x==1"},
+			{From: "start", To: "bad", Cond: "invalid!!!"},
+		}}
+		compiled := graph.Compile(nil)
+
+		// Act
+		next, _, err := compiled.next("start", map[string]any{"x": 1}, false)
+		_, _, badErr := compiled.next("start", map[string]any{"x": 2}, false)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", next)
+		assert.ErrorIs(t, badErr, ErrInvalidCondition)
+	})
+}
+
+// wideGraphDOT builds a chain of depth nodes where every node has fanout outgoing
+// edges and only the last one matches, so each step must evaluate every edge.
+func wideGraphDOT(depth, fanout int) string {
+	var b strings.Builder
+	b.WriteString("digraph { start [result=\"\"]; ")
+	prev := StartNodeID
+	for i := 0; i < depth; i++ {
+		node := fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "%s [result=\"step=%d\"]; ", node, i)
+		for j := 0; j < fanout-1; j++ {
+			fmt.Fprintf(&b, "%s -> miss_%d_%d [cond=\"score==%d\"]; ", prev, i, j, 1000+j)
+		}
+		fmt.Fprintf(&b, "%s -> %s [cond=\"score>=0\"]; ", prev, node)
+		prev = node
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// linearNextNode is the pre-compilation lookup: scan every edge and parse each condition on use.
+func linearNextNode(current string, graph *Graph, vars map[string]any) (string, error) {
+	for _, edge := range graph.Edges {
+		if edge.From != current {
+			continue
+		}
+		ok, err := EvalCondition(edge.Cond, vars)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return edge.To, nil
+		}
+	}
+	return "", nil
+}
+
+func benchmarkGraph(b *testing.B) *Graph {
+	b.Helper()
+	graph, err := NewDotParser(nil).Parse(context.Background(), wideGraphDOT(50, 40))
+	require.NoError(b, err)
+	return graph
+}
+
+func BenchmarkProcessLinearScan(b *testing.B) {
+	graph := benchmarkGraph(b)
+	input := map[string]any{"score": 10}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vars := copyInputToOutput(input)
+		current := graph.Start
+		for current != "" {
+			if node := graph.Nodes[current]; node != nil {
+				ApplyResult(node.Result, vars)
+			}
+			next, err := linearNextNode(current, graph, vars)
+			if err != nil {
+				b.Fatal(err)
+			}
+			current = next
+		}
+	}
+}
+
+func BenchmarkProcessCompiled(b *testing.B) {
+	graph := benchmarkGraph(b)
+	graph.Compile(nil)
+	executor := NewGraphExecutor(nil)
+	input := map[string]any{"score": 10}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := executor.Process(context.Background(), graph, input, ExecOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package policy
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxCoverageSamples bounds the assignments tried per node; nodes needing more are not analyzed.
+const maxCoverageSamples = 4096
+
+// coverageFindings reports, for the outgoing edges of from, pairs of conditions with the same
+// priority that can both hold (the executor silently takes the one declared first) and inputs no
+// condition matches; a default or trailing unconditional edge matches everything. It only reasons
+// about simple conditions: comparisons between a variable and a literal, `in` with a literal
+// list, combined with && and ||. If any condition of the node is not simple, or does not
+// compile, the node is skipped.
+//
+// The values that matter for such conditions are the literals themselves, so each variable is
+// sampled at every literal it is compared with, between consecutive numbers and beyond both
+// ends, and at one string or number no literal names. Every combination of samples is then
+// evaluated with the real evaluator, which makes the result exact for simple conditions.
+func coverageFindings(from string, edges []*Edge, functions *FunctionRegistry, severity Severity) []Finding {
+	edges = evaluationOrder(edges)
+	conds := make([]condition, len(edges))
+	literals := make(map[string][]any)
+	fallback := false
+	for i, edge := range edges {
+		conds[i] = compileCondition(edge.Cond, functions)
+		if conds[i].err != nil {
+			return nil
+		}
+		if conds[i].ast == nil {
+			fallback = true
+			continue
+		}
+		if !collectLiterals(conds[i].ast, literals) {
+			return nil
+		}
+	}
+	samples, ok := coverageSamples(literals)
+	if !ok {
+		return nil
+	}
+
+	overlaps := make(map[[2]int]string)
+	uncovered := ""
+	for _, vars := range samples {
+		var matched []int
+		for i, cond := range conds {
+			if ok, err := cond.eval(vars.values); err == nil && ok {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) == 0 && !fallback && uncovered == "" {
+			uncovered = vars.desc
+		}
+		for a, i := range matched {
+			for _, j := range matched[a+1:] {
+				// A later unconditional edge is the usual fallback, and distinct priorities
+				// order the edges explicitly; neither is an ambiguity.
+				if conds[j].ast == nil && conds[i].ast != nil || edges[i].Priority != edges[j].Priority {
+					continue
+				}
+				if _, seen := overlaps[[2]int{i, j}]; !seen {
+					overlaps[[2]int{i, j}] = vars.desc
+				}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, pair := range slices.SortedFunc(maps.Keys(overlaps), func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	}) {
+		first, second := edges[pair[0]], edges[pair[1]]
+		findings = append(findings, Finding{
+			Severity: severity, Code: FindingOverlappingConditions, From: from, To: second.To,
+			Message: fmt.Sprintf("edges %s and %s both match when %s; the one declared first is taken", describeEdge(first), describeEdge(second), overlaps[pair]),
+		})
+	}
+	if uncovered != "" {
+		findings = append(findings, Finding{
+			Severity: severity, Code: FindingNonExhaustive, Node: from,
+			Message: fmt.Sprintf("no edge from %s matches when %s", from, uncovered),
+		})
+	}
+	return findings
+}
+
+// collectLiterals records, per variable, the literals n compares it with, and reports whether n
+// is simple enough for coverageFindings.
+func collectLiterals(n exprNode, literals map[string][]any) bool {
+	switch n := n.(type) {
+	case *logicalNode:
+		return collectLiterals(n.left, literals) && collectLiterals(n.right, literals)
+	case *comparisonNode:
+		ident, other := n.left, n.right
+		if _, ok := ident.(*identNode); !ok {
+			ident, other = other, ident
+		}
+		id, ok := ident.(*identNode)
+		if !ok || slices.ContainsFunc(id.path, func(s pathSegment) bool { return s.isIndex }) {
+			return false
+		}
+		var values []any
+		switch other := other.(type) {
+		case *literalNode:
+			values = []any{other.value}
+		case *listNode:
+			if n.op != tokIn {
+				return false
+			}
+			values = other.items
+		default:
+			return false
+		}
+		for _, v := range values {
+			if _, isString := v.(string); isString && n.op != tokEq && n.op != tokNe && n.op != tokIn {
+				// Strings between two literals cannot be enumerated.
+				return false
+			}
+		}
+		literals[id.name] = append(literals[id.name], values...)
+		return true
+	default:
+		return false
+	}
+}
+
+type coverageSample struct {
+	values map[string]any
+	desc   string
+}
+
+// coverageSamples returns every combination of the sample values of each variable, or false
+// when a variable is compared with literals of different types or there are too many combinations.
+func coverageSamples(literals map[string][]any) ([]coverageSample, bool) {
+	names := slices.Sorted(maps.Keys(literals))
+	perVar := make([][]any, len(names))
+	total := 1
+	for i, name := range names {
+		values, ok := sampleValues(literals[name])
+		if !ok {
+			return nil, false
+		}
+		perVar[i] = values
+		total *= len(values)
+		if total > maxCoverageSamples {
+			return nil, false
+		}
+	}
+
+	samples := make([]coverageSample, 0, total)
+	for k := range total {
+		vars := make(map[string]any)
+		desc := make([]string, len(names))
+		for i := len(names) - 1; i >= 0; i-- {
+			value := perVar[i][k%len(perVar[i])]
+			k /= len(perVar[i])
+			if !setPath(vars, names[i], value) {
+				return nil, false
+			}
+			desc[i] = names[i] + "=" + formatSample(value)
+		}
+		samples = append(samples, coverageSample{values: vars, desc: strings.Join(desc, ", ")})
+	}
+	return samples, true
+}
+
+// sampleValues returns one value per region the literals split the variable's domain into.
+func sampleValues(literals []any) ([]any, bool) {
+	switch typeOf(literals[0]) {
+	case TypeNumber:
+		var points []float64
+		for _, l := range literals {
+			f, ok := toFloat(l)
+			if !ok {
+				return nil, false
+			}
+			points = append(points, f)
+		}
+		slices.Sort(points)
+		points = slices.Compact(points)
+		values := []any{points[0] - 1}
+		for i, p := range points {
+			if i > 0 {
+				values = append(values, (points[i-1]+p)/2)
+			}
+			values = append(values, p)
+		}
+		return append(values, points[len(points)-1]+1), true
+	case TypeString:
+		var values []any
+		for _, l := range literals {
+			s, ok := l.(string)
+			if !ok {
+				return nil, false
+			}
+			if !slices.Contains(values, any(s)) {
+				values = append(values, s)
+			}
+		}
+		other := ""
+		for n := 1; slices.Contains(values, any(other)); n++ {
+			other = "other" + strconv.Itoa(n)
+		}
+		return append(values, other), true
+	case TypeBool:
+		for _, l := range literals {
+			if _, ok := l.(bool); !ok {
+				return nil, false
+			}
+		}
+		return []any{false, true}, true
+	default:
+		return nil, false
+	}
+}
+
+func formatSample(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func describeEdge(edge *Edge) string {
+	if edge.Cond == "" {
+		return fmt.Sprintf("%s -> %s", edge.From, edge.To)
+	}
+	return fmt.Sprintf("%s -> %s [%s]", edge.From, edge.To, edge.Cond)
+}
@@ -1,9 +1,93 @@
 package policy
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ErrNoStartNode      = errors.New("graph has no node named start")
-	ErrInvalidPolicyDot = errors.New("invalid policy dot")
-	ErrInvalidCondition = errors.New("invalid condition")
+	ErrNoStartNode        = errors.New("graph has no node named start")
+	ErrInvalidPolicyDot   = errors.New("invalid policy dot")
+	ErrInvalidCondition   = errors.New("invalid condition")
+	ErrCycleDetected      = errors.New("cycle detected")
+	ErrStepBudgetExceeded = errors.New("step budget exceeded")
+	ErrTimeout            = errors.New("policy evaluation timed out")
+	ErrInvalidFunction    = errors.New("invalid condition function")
+	ErrDivisionByZero     = errors.New("division by zero")
+	ErrArithmeticOverflow = errors.New("arithmetic overflow")
+	ErrInvalidResult      = errors.New("invalid result")
 )
+
+// CycleError is returned in strict mode when execution would revisit a node.
+// Path starts and ends at the revisited node.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCycleDetected, strings.Join(e.Path, " -> "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrCycleDetected
+}
+
+// ConditionError describes why a condition was rejected; Pos is the byte offset of the offending token.
+// Err, when set, classifies an evaluation failure such as ErrDivisionByZero.
+type ConditionError struct {
+	Cond string
+	Pos  int
+	Msg  string
+	Err  error
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("%s %s", ErrInvalidCondition, e.Detail())
+}
+
+// Detail describes the offending condition and column without the ErrInvalidCondition prefix.
+func (e *ConditionError) Detail() string {
+	return fmt.Sprintf("%q: %s at column %d", e.Cond, e.Msg, e.Pos+1)
+}
+
+func (e *ConditionError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrInvalidCondition, e.Err}
+	}
+	return []error{ErrInvalidCondition}
+}
+
+// ResultError describes why a node result was rejected; Pos is the byte offset of the offending pair or character.
+// Err, when set, classifies a failure computing a value such as ErrDivisionByZero.
+type ResultError struct {
+	Result string
+	Pos    int
+	Msg    string
+	Err    error
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("%s %s", ErrInvalidResult, e.Detail())
+}
+
+// Detail describes the offending result and column without the ErrInvalidResult prefix.
+func (e *ResultError) Detail() string {
+	return fmt.Sprintf("%q: %s at column %d", e.Result, e.Msg, e.Pos+1)
+}
+
+func (e *ResultError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrInvalidResult, e.Err}
+	}
+	return []error{ErrInvalidResult}
+}
+
+// checkContext returns ErrTimeout wrapping the context error once ctx is cancelled or past its deadline.
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return nil
+}
@@ -1,61 +1,11 @@
 package policy
 
-import (
-	"regexp"
-	"strconv"
-	"strings"
-
-	"github.com/casbin/govaluate"
-)
-
-const arithmeticCondChars = "+*/"
-
-var validCondRegex = regexp.MustCompile(
-	`^\s*[a-zA-Z_]\w*\s*(==|!=|>=|<=|>|<)\s*(-?\d+(?:\.\d+)?|"[^"]*"|true|false)(\s*(?:&&|\|\|)\s*[a-zA-Z_]\w*\s*(==|!=|>=|<=|>|<)\s*(-?\d+(?:\.\d+)?|"[^"]*"|true|false))*\s*$`,
-)
-
-func isValidCond(cond string) bool {
-	if strings.ContainsAny(cond, arithmeticCondChars) {
-		return false
-	}
-	return validCondRegex.MatchString(cond)
-}
+import "strconv"
 
+// EvalCondition parses and evaluates cond in one go with the built-in functions. Hot paths use
+// the conditions cached by Graph.Compile instead.
 func EvalCondition(cond string, vars map[string]any) (bool, error) {
-	if cond == "" {
-		return true, nil
-	}
-	if !isValidCond(cond) {
-		return false, ErrInvalidCondition
-	}
-	expr, err := govaluate.NewEvaluableExpression(cond)
-	if err != nil {
-		return false, ErrInvalidCondition
-	}
-	evars := make(map[string]interface{})
-	for k, v := range vars {
-		evars[k] = v
-	}
-	result, err := expr.Evaluate(evars)
-	if err != nil {
-		return false, ErrInvalidCondition
-	}
-	b, ok := result.(bool)
-	if !ok {
-		return false, nil
-	}
-	return b, nil
-}
-
-func parseKeyValue(pair string) (key, value string, ok bool) {
-	kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
-	if len(kv) != 2 {
-		return "", "", false
-	}
-	key = strings.TrimSpace(kv[0])
-	value = strings.TrimSpace(kv[1])
-	value = strings.Trim(value, "\"")
-	return key, value, true
+	return compileCondition(cond, builtinRegistry).eval(vars)
 }
 
 func parseResultValue(valStr string) any {
@@ -68,17 +18,14 @@ func parseResultValue(valStr string) any {
 	return valStr
 }
 
-func ApplyResult(result string, vars map[string]any) {
-	result = strings.TrimSpace(result)
-	if result == "" {
-		return
-	}
-	pairs := strings.Split(result, ",")
-	for _, pair := range pairs {
-		key, valStr, ok := parseKeyValue(pair)
-		if !ok {
-			continue
-		}
-		vars[key] = parseResultValue(valStr)
-	}
+// ApplyResult writes the key=value pairs of a node result into vars, in order, and returns the
+// pairs it wrote. A dotted key such as decision.status writes into nested objects, creating them
+// as needed, and a value that is an expression, such as score=score+50, is computed from vars
+// with the built-in functions. A malformed result, a value that cannot be computed, or a key
+// crossing a value that is not an object returns a *ResultError.
+// Hot paths use the results cached by Graph.Compile instead.
+func ApplyResult(result string, vars map[string]any) (map[string]any, error) {
+	compiled := compileResult(result, builtinRegistry)
+	keepCounters([]compiledResult{compiled})
+	return compiled.apply(vars)
 }
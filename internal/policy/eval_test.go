@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEvalCondition(t *testing.T) {
@@ -79,7 +80,7 @@ func TestEvalCondition(t *testing.T) {
 		assert.Error(t, err)
 		assert.False(t, got)
 	})
-	t.Run("arithmetic plus returns invalid", func(t *testing.T) {
+	t.Run("arithmetic plus allowed", func(t *testing.T) {
 		// Arrange
 		cond := "age+1>=18"
 		vars := map[string]any{"age": 17}
@@ -88,10 +89,10 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
-	t.Run("arithmetic star returns invalid", func(t *testing.T) {
+	t.Run("arithmetic star allowed", func(t *testing.T) {
 		// Arrange
 		cond := "score*2>100"
 		vars := map[string]any{"score": 60}
@@ -100,7 +101,72 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("variable compared with variable", func(t *testing.T) {
+		// Arrange
+		cond := "requested_amount <= credit_limit"
+		vars := map[string]any{"requested_amount": 500, "credit_limit": 1000.0}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("literal on the left of a comparison", func(t *testing.T) {
+		// Arrange
+		cond := "18 <= age"
+		vars := map[string]any{"age": 17}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, got)
+	})
+	t.Run("missing operand is reported by name", func(t *testing.T) {
+		// Arrange
+		cond := "requested_amount <= credit_limit"
+		vars := map[string]any{"requested_amount": 500}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "variable credit_limit is not set", condErr.Msg)
+		assert.Equal(t, 20, condErr.Pos)
+		assert.False(t, got)
+	})
+	t.Run("mistyped operand is reported by name", func(t *testing.T) {
+		// Arrange
+		cond := "requested_amount <= credit_limit"
+		vars := map[string]any{"requested_amount": 500, "credit_limit": "high"}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, `variable credit_limit is string but "<=" needs two numbers or two strings (other operand is number)`, condErr.Msg)
+		assert.False(t, got)
+	})
+	t.Run("comparing two literals invalid", func(t *testing.T) {
+		// Arrange
+		cond := "1 == 1"
+		vars := map[string]any{}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidCondition)
 		assert.False(t, got)
 	})
 	t.Run("allowed && and ||", func(t *testing.T) {
@@ -151,7 +217,7 @@ func TestEvalCondition(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, got)
 	})
-	t.Run("arithmetic plus with spaces invalid", func(t *testing.T) {
+	t.Run("arithmetic plus with spaces allowed", func(t *testing.T) {
 		// Arrange
 		cond := "age + 1 >= 18"
 		vars := map[string]any{"age": 17}
@@ -160,10 +226,10 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
-	t.Run("arithmetic star invalid", func(t *testing.T) {
+	t.Run("arithmetic star with equality allowed", func(t *testing.T) {
 		// Arrange
 		cond := "score*2 == 1400"
 		vars := map[string]any{"score": 700}
@@ -172,10 +238,10 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
-	t.Run("arithmetic slash invalid", func(t *testing.T) {
+	t.Run("arithmetic slash allowed", func(t *testing.T) {
 		// Arrange
 		cond := "points / 2 > 100"
 		vars := map[string]any{"points": 250}
@@ -184,10 +250,10 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
-	t.Run("arithmetic minus invalid", func(t *testing.T) {
+	t.Run("arithmetic minus allowed", func(t *testing.T) {
 		// Arrange
 		cond := "balance - 50 > 0"
 		vars := map[string]any{"balance": 100}
@@ -196,8 +262,8 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
 	t.Run("triple equals invalid", func(t *testing.T) {
 		// Arrange
@@ -247,7 +313,7 @@ func TestEvalCondition(t *testing.T) {
 		assert.Error(t, err)
 		assert.False(t, got)
 	})
-	t.Run("parentheses not supported", func(t *testing.T) {
+	t.Run("parentheses around a comparison allowed", func(t *testing.T) {
 		// Arrange
 		cond := "age>=18 && (score>700)"
 		vars := map[string]any{"age": 25, "score": 720}
@@ -256,10 +322,10 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
-		assert.False(t, got)
+		assert.NoError(t, err)
+		assert.True(t, got)
 	})
-	t.Run("parentheses around comparisons invalid", func(t *testing.T) {
+	t.Run("parentheses around comparisons allowed", func(t *testing.T) {
 		// Arrange
 		cond := `(name == "João") || (age < 20)`
 		vars := map[string]any{"name": "João", "age": 18}
@@ -268,10 +334,46 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("grouped expression overrides precedence", func(t *testing.T) {
+		// Arrange
+		cond := "(age>=18 && score>700) || vip==true"
+		vars := map[string]any{"age": 16, "score": 720, "vip": true}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("&& binds tighter than ||", func(t *testing.T) {
+		// Arrange
+		cond := "vip==true || age>=18 && score>700"
+		vars := map[string]any{"vip": false, "age": 25, "score": 600}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
 		assert.False(t, got)
 	})
-	t.Run("function call len invalid", func(t *testing.T) {
+	t.Run("unbalanced parentheses invalid", func(t *testing.T) {
+		// Arrange
+		cond := "(age>=18 && score>700"
+		vars := map[string]any{"age": 25, "score": 720}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidCondition)
+		assert.False(t, got)
+	})
+	t.Run("function call len allowed", func(t *testing.T) {
 		// Arrange
 		cond := "len(name) > 5"
 		vars := map[string]any{"name": "Alice"}
@@ -280,7 +382,7 @@ func TestEvalCondition(t *testing.T) {
 		got, err := EvalCondition(cond, vars)
 
 		// Assert
-		assert.Error(t, err)
+		assert.NoError(t, err)
 		assert.False(t, got)
 	})
 	t.Run("function call isAdult invalid", func(t *testing.T) {
@@ -376,9 +478,10 @@ func TestApplyResult(t *testing.T) {
 		vars := map[string]any{"a": 1}
 
 		// Act
-		ApplyResult(result, vars)
+		_, err := ApplyResult(result, vars)
 
 		// Assert
+		require.NoError(t, err)
 		assert.Len(t, vars, 1)
 		assert.Equal(t, 1, vars["a"])
 	})
@@ -388,9 +491,10 @@ func TestApplyResult(t *testing.T) {
 		vars := map[string]any{}
 
 		// Act
-		ApplyResult(result, vars)
+		_, err := ApplyResult(result, vars)
 
 		// Assert
+		require.NoError(t, err)
 		assert.Equal(t, "foo", vars["name"])
 	})
 	t.Run("key=true sets bool", func(t *testing.T) {
@@ -399,9 +503,10 @@ func TestApplyResult(t *testing.T) {
 		vars := map[string]any{}
 
 		// Act
-		ApplyResult(result, vars)
+		_, err := ApplyResult(result, vars)
 
 		// Assert
+		require.NoError(t, err)
 		assert.Equal(t, true, vars["approved"])
 	})
 	t.Run("multiple pairs", func(t *testing.T) {
@@ -410,23 +515,216 @@ func TestApplyResult(t *testing.T) {
 		vars := map[string]any{}
 
 		// Act
-		ApplyResult(result, vars)
+		_, err := ApplyResult(result, vars)
 
 		// Assert
+		require.NoError(t, err)
 		assert.Equal(t, 2.5, vars["num"])
 		assert.Equal(t, true, vars["flag"])
 	})
-	t.Run("malformed pair without equals is skipped", func(t *testing.T) {
+	t.Run("returns the assigned pairs", func(t *testing.T) {
+		// Arrange
+		result := "segment=prime, approved=true"
+		vars := map[string]any{"age": 20}
+
+		// Act
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"segment": "prime", "approved": true}, assigned)
+	})
+	t.Run("dotted key writes nested objects", func(t *testing.T) {
+		// Arrange
+		result := "decision.status=approved, decision.limit=5000"
+		vars := map[string]any{"decision": map[string]any{"reason": "score"}}
+
+		// Act
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"reason": "score", "status": "approved", "limit": 5000.0}, vars["decision"])
+		assert.Equal(t, map[string]any{"decision.status": "approved", "decision.limit": 5000.0}, assigned)
+	})
+	t.Run("dotted key crossing a non-object returns ResultError", func(t *testing.T) {
+		// Arrange
+		result := "ok=true, name.first=Ana"
+		vars := map[string]any{"name": "Ana"}
+
+		// Act
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.Equal(t, 9, resultErr.Pos)
+		assert.Equal(t, "name.first crosses a value that is not an object", resultErr.Msg)
+		assert.ErrorIs(t, err, ErrInvalidResult)
+		assert.Equal(t, "Ana", vars["name"])
+		assert.Nil(t, assigned)
+	})
+	t.Run("computed values read current variables in order", func(t *testing.T) {
+		// Arrange
+		result := "score=score+50, bonus = score*2, long=len(tier) > 3 && score > 100"
+		vars := map[string]any{"score": 100, "tier": "gold"}
+
+		// Act
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), vars["score"])
+		assert.Equal(t, int64(300), vars["bonus"])
+		assert.Equal(t, map[string]any{"score": int64(150), "bonus": int64(300), "long": true}, assigned)
+	})
+	t.Run("values that are not expressions keep their literal meaning", func(t *testing.T) {
+		// Arrange
+		result := `due=2024-01-01, delta=-1, status="pre-approved", msg=hello world, sum=1+2, segment=high-risk, domain=example.com`
+		vars := map[string]any{"high": 1, "example": map[string]any{"com": 2}}
+
+		// Act
+		_, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-01", vars["due"])
+		assert.Equal(t, -1.0, vars["delta"])
+		assert.Equal(t, "pre-approved", vars["status"])
+		assert.Equal(t, "hello world", vars["msg"])
+		assert.Equal(t, "1+2", vars["sum"])
+		assert.Equal(t, "high-risk", vars["segment"])
+		assert.Equal(t, "example.com", vars["domain"])
+	})
+	t.Run("expression over variables that are not all set is text", func(t *testing.T) {
+		// Arrange
+		result := "segment=high-risk, total=score+bonus"
+		vars := map[string]any{"score": 700}
+
+		// Act
+		_, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "high-risk", vars["segment"])
+		assert.Equal(t, "score+bonus", vars["total"])
+	})
+	t.Run("expression over variables that are not all set never replaces a typed value", func(t *testing.T) {
+		// Arrange
+		result := "ok=true, score=bonus+50"
+		vars := map[string]any{"score": 700}
+
+		// Act
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.Equal(t, 15, resultErr.Pos)
+		assert.Equal(t, `score is number; "bonus+50" reads a variable that is not set and would replace it with text`, resultErr.Msg)
+		assert.Equal(t, 700, vars["score"])
+		assert.Nil(t, assigned)
+	})
+	t.Run("literal numbers read by a computed value stay numbers", func(t *testing.T) {
+		// Arrange
+		vars := map[string]any{}
+
+		// Act
+		_, err := ApplyResult("score=0, n=1, t=T, score=score+50", vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, vars["score"])
+		assert.Equal(t, true, vars["n"])
+		assert.Equal(t, true, vars["t"])
+	})
+	t.Run("commas inside calls do not split pairs", func(t *testing.T) {
+		// Arrange
+		result := `flag=contains(name, "a"), ok=true`
+		vars := map[string]any{"name": "Ana"}
+
+		// Act
+		_, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, true, vars["flag"])
+		assert.Equal(t, true, vars["ok"])
+	})
+	t.Run("computed value reading a missing variable returns error", func(t *testing.T) {
 		// Arrange
-		result := "a=1, badpair, b=2"
+		result := "ok=true, score=score+50"
 		vars := map[string]any{}
 
 		// Act
-		ApplyResult(result, vars)
+		assigned, err := ApplyResult(result, vars)
+
+		// Assert
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.Equal(t, 15, resultErr.Pos)
+		assert.Equal(t, "score: variable score is not set", resultErr.Msg)
+		assert.NotErrorIs(t, err, ErrInvalidCondition)
+		assert.Nil(t, assigned)
+	})
+	t.Run("computed value failing arithmetic keeps its cause", func(t *testing.T) {
+		// Act
+		_, err := ApplyResult("ratio=score/0", map[string]any{"score": 10})
 
 		// Assert
-		assert.Equal(t, true, vars["a"])
-		assert.Equal(t, 2.0, vars["b"])
-		assert.Len(t, vars, 2)
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.ErrorIs(t, err, ErrInvalidResult)
+		assert.ErrorIs(t, err, ErrDivisionByZero)
+	})
+	t.Run("quoted values keep commas, equals signs and escaped quotes", func(t *testing.T) {
+		// Arrange
+		result := `reason="age, score", note = "a=b \"c\" \\ d" , flag="true", status=approved`
+		vars := map[string]any{}
+
+		// Act
+		_, err := ApplyResult(result, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "age, score", vars["reason"])
+		assert.Equal(t, `a=b "c" \ d`, vars["note"])
+		assert.Equal(t, "true", vars["flag"])
+		assert.Equal(t, "approved", vars["status"])
+		assert.Len(t, vars, 4)
+	})
+	t.Run("malformed results return ResultError", func(t *testing.T) {
+		cases := []struct {
+			result string
+			pos    int
+			msg    string
+		}{
+			{result: "a=1, badpair, b=2", pos: 5, msg: `expected = after "badpair"`},
+			{result: "a=1,", pos: 4, msg: "expected key=value pair"},
+			{result: "a=1,, b=2", pos: 4, msg: "expected key=value pair"},
+			{result: "=1", pos: 0, msg: "missing key before ="},
+			{result: "a b=1", pos: 0, msg: `invalid key "a b"`},
+			{result: "a..b=1", pos: 0, msg: `invalid key "a..b"`},
+			{result: `reason="age, score`, pos: 7, msg: "unterminated string"},
+			{result: `reason="age" score`, pos: 13, msg: "unexpected text after quoted value"},
+			{result: `flag=contains(name, "a"`, pos: 13, msg: `unclosed '('`},
+			{result: "a=x), b=1", pos: 3, msg: `unexpected ')'`},
+			{result: `a=b"c"`, pos: 3, msg: `a: unexpected string "c"`},
+			{result: "a=1, b=(score +)", pos: 15, msg: `b: expected variable or literal, found ")"`},
+			{result: "b=len(x, y)", pos: 2, msg: "b: len takes 1 arguments, got 2"},
+			{result: "risk=band(score)", pos: 5, msg: "risk: unknown function band"},
+			{result: "ok=score > 1 && x", pos: 16, msg: "ok: variable is not allowed as condition"},
+		}
+		for _, tc := range cases {
+			// Act
+			_, err := ApplyResult(tc.result, map[string]any{})
+
+			// Assert
+			var resultErr *ResultError
+			require.ErrorAs(t, err, &resultErr, tc.result)
+			assert.Equal(t, tc.pos, resultErr.Pos, tc.result)
+			assert.Equal(t, tc.msg, resultErr.Msg, tc.result)
+			assert.ErrorIs(t, err, ErrInvalidResult)
+		}
 	})
 }
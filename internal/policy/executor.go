@@ -1,56 +1,117 @@
 package policy
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
-type GraphExecutor struct{}
+type GraphExecutor struct {
+	functions *FunctionRegistry
+}
 
-func NewGraphExecutor() *GraphExecutor {
-	return &GraphExecutor{}
+// NewGraphExecutor returns an executor whose conditions may call the functions in functions;
+// nil means the built-ins only.
+func NewGraphExecutor(functions *FunctionRegistry) *GraphExecutor {
+	return &GraphExecutor{functions: functions.orBuiltins()}
 }
 
-func (GraphExecutor) Process(ctx context.Context, graph *Graph, input map[string]any) (InferResponse, error) {
+func (e GraphExecutor) Process(ctx context.Context, graph *Graph, input map[string]any, opts ExecOptions) (InferResponse, error) {
 	out := copyInputToOutput(input)
-	visited := make(map[string]bool)
+	var trace []TraceStep
+	var termination Termination
+	budget := stepBudget(graph, opts)
+	// Only visit-once mode needs the walk so far, to stop at or report a revisit.
+	var visited map[string]bool
+	var path []string
+	if budget == 0 {
+		visited = make(map[string]bool)
+	}
+	compiled := graph.Compile(e.functions)
 	current := graph.Start
-	for {
-		if node := graph.Nodes[current]; node != nil {
-			ApplyResult(node.Result, out)
+	for steps := 1; termination == ""; steps++ {
+		if err := checkContext(ctx); err != nil {
+			return InferResponse{}, err
+		}
+		if budget > 0 && steps > budget {
+			return InferResponse{}, fmt.Errorf("%w: %d steps", ErrStepBudgetExceeded, budget)
 		}
-		visited[current] = true
-		next, err := findNextNode(current, graph, out)
+		assigned, err := compiled.apply(current, out)
 		if err != nil {
 			return InferResponse{}, err
 		}
-		if next == "" || visited[next] {
-			break
+		step := TraceStep{Node: current, Assigned: assigned}
+		if visited != nil {
+			visited[current] = true
+			path = append(path, current)
+		}
+		next, evals, err := compiled.next(current, out, opts.Explain)
+		if opts.Explain {
+			step.Edges = evals
+			trace = append(trace, step)
+		}
+		if err != nil {
+			return InferResponse{}, err
+		}
+		switch {
+		case next == "":
+			termination = terminationAt(current, graph)
+		case budget > 0:
+			current = next
+		case visited[next] && opts.Strict:
+			return InferResponse{}, &CycleError{Path: cyclePath(path, next)}
+		case visited[next]:
+			termination = TerminationCycleDetected
+		default:
+			current = next
 		}
-		current = next
 	}
-	return InferResponse{Output: out}, nil
+	return InferResponse{Output: out, Node: current, Termination: termination, Trace: trace}, nil
 }
 
-func copyInputToOutput(input map[string]any) map[string]any {
-	out := make(map[string]any, len(input))
-	for k, v := range input {
-		out[k] = v
+// MaxStepBudget caps the loop-aware step budget, whether it comes from the graph or the request.
+const MaxStepBudget = 100_000
+
+// stepBudget returns the loop-aware step budget for this run, or 0 for visit-once semantics.
+// The request may lower the graph's budget but never raise it, and neither may exceed
+// MaxStepBudget.
+func stepBudget(graph *Graph, opts ExecOptions) int {
+	limit := MaxStepBudget
+	if graph.MaxSteps > 0 {
+		limit = min(graph.MaxSteps, MaxStepBudget)
 	}
-	return out
+	if opts.MaxSteps > 0 {
+		return min(opts.MaxSteps, limit)
+	}
+	if graph.MaxSteps > 0 {
+		return limit
+	}
+	return 0
 }
 
-// findNextNode returns the first outgoing edge from current whose condition evaluates to true (deterministic single path).
-func findNextNode(current string, graph *Graph, vars map[string]any) (string, error) {
-	for _, edge := range graph.Edges {
-		if edge.From != current {
-			continue
-		}
-		ok, err := EvalCondition(edge.Cond, vars)
-		if err != nil {
-			return "", err
-		}
-		if !ok {
-			continue
+// terminationAt reports why a walk with no matching outgoing edge stopped at current:
+// nodes only referenced by an edge (never declared by a node statement) are reported separately.
+func terminationAt(current string, graph *Graph) Termination {
+	if _, declared := graph.Nodes[current]; !declared {
+		return TerminationUndeclaredNode
+	}
+	return TerminationNoMatchingEdge
+}
+
+// cyclePath returns the tail of path that starts at the revisited node, closed by that node again.
+func cyclePath(path []string, revisited string) []string {
+	for i, id := range path {
+		if id == revisited {
+			cycle := append([]string{}, path[i:]...)
+			return append(cycle, revisited)
 		}
-		return edge.To, nil
 	}
-	return "", nil
+	return []string{revisited}
+}
+
+func copyInputToOutput(input map[string]any) map[string]any {
+	out := make(map[string]any, len(input))
+	for k, v := range input {
+		out[k] = deepCopy(v)
+	}
+	return out
 }
@@ -2,6 +2,8 @@ package policy
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,35 +13,73 @@ import (
 func TestExecute(t *testing.T) {
 	linearDOT := `digraph { start [result=""]; ok [result="approved=true"]; no [result="approved=false"]; start -> ok [cond="age>=18"]; start -> no [cond="age<18"]; }`
 	cycleDOT := `digraph { start [result=""]; a [result="done=true"]; start -> a [cond="x==1"]; a -> a [cond="x==1"]; }`
+	ladderDOT := `digraph { graph [max_steps=10]; start [result="attempt=2"]; check [result=""]; retry [result="attempt=3"]; escalate [result="attempt=4"]; end [result="escalated=true"]; start -> check; check -> retry [cond="attempt==2"]; retry -> check; check -> escalate [cond="attempt==3"]; escalate -> check; check -> end [cond="attempt==4"]; }`
 	singleNodeDOT := `digraph { start [result="x=1"]; }`
 	edgeToMissingNodeDOT := `digraph { start [result="done=true"]; start -> ghost [cond="x==1"]; }`
 
 	t.Run("single path applies node results", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
-		executor := NewGraphExecutor()
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
 		graph, err := parser.Parse(context.Background(), linearDOT)
 		require.NoError(t, err)
 		vars := map[string]any{"age": 20}
 
 		// Act
-		resp, err := executor.Process(context.Background(), graph, vars)
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, 20, resp.Output["age"])
 		assert.Equal(t, true, resp.Output["approved"])
+		assert.Equal(t, "ok", resp.Node)
+		assert.Equal(t, TerminationNoMatchingEdge, resp.Termination)
+	})
+	t.Run("condition compares input with a variable set by an earlier result", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		dot := `digraph { start [result="credit_limit=1000"]; ok [result="approved=true"]; start -> ok [cond="requested_amount <= credit_limit"]; }`
+		graph, err := parser.Parse(context.Background(), dot)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, map[string]any{"requested_amount": 800}, ExecOptions{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", resp.Node)
+		assert.Equal(t, true, resp.Output["approved"])
+	})
+	t.Run("nested input drives conditions and nested results leave input untouched", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		dot := `digraph { start [result=""]; ok [result="customer.decision=approved"]; start -> ok [cond="customer.address.zone >= 2 && items[0].price > 10"]; }`
+		graph, err := parser.Parse(context.Background(), dot)
+		require.NoError(t, err)
+		customer := map[string]any{"address": map[string]any{"zone": 3}}
+		input := map[string]any{"customer": customer, "items": []any{map[string]any{"price": 20.0}}}
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, input, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Node)
+		assert.Equal(t, "approved", resp.Output["customer"].(map[string]any)["decision"])
+		assert.NotContains(t, customer, "decision")
 	})
 	t.Run("single path age under 18", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
-		executor := NewGraphExecutor()
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
 		graph, err := parser.Parse(context.Background(), linearDOT)
 		require.NoError(t, err)
 		vars := map[string]any{"age": 15}
 
 		// Act
-		resp, err := executor.Process(context.Background(), graph, vars)
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
 
 		// Assert
 		assert.NoError(t, err)
@@ -47,48 +87,287 @@ func TestExecute(t *testing.T) {
 	})
 	t.Run("graph with cycle terminates and returns", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
-		executor := NewGraphExecutor()
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
 		graph, err := parser.Parse(context.Background(), cycleDOT)
 		require.NoError(t, err)
 		vars := map[string]any{"x": 1.0}
 
 		// Act
-		resp, err := executor.Process(context.Background(), graph, vars)
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, 1.0, resp.Output["x"])
 		assert.Equal(t, true, resp.Output["done"])
+		assert.Equal(t, "a", resp.Node)
+		assert.Equal(t, TerminationCycleDetected, resp.Termination)
 	})
 	t.Run("single node no edges empty input returns only node result", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
-		executor := NewGraphExecutor()
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
 		graph, err := parser.Parse(context.Background(), singleNodeDOT)
 		require.NoError(t, err)
 		vars := map[string]any{}
 
 		// Act
-		resp, err := executor.Process(context.Background(), graph, vars)
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, map[string]any{"x": true}, resp.Output)
+		assert.Equal(t, "start", resp.Node)
+		assert.Equal(t, TerminationNoMatchingEdge, resp.Termination)
+	})
+	t.Run("node created by an edge applies the default result", func(t *testing.T) {
+		// Arrange
+		graph, err := NewDotParser(nil).Parse(context.Background(), `digraph { node [result="x=1"]; start -> a; }`)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := NewGraphExecutor(nil).Process(context.Background(), graph, map[string]any{}, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "a", resp.Node)
+		assert.Equal(t, TerminationNoMatchingEdge, resp.Termination)
+		assert.Equal(t, map[string]any{"x": true}, resp.Output)
 	})
 	t.Run("edge to missing node applies start result then stops", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
-		executor := NewGraphExecutor()
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
 		graph, err := parser.Parse(context.Background(), edgeToMissingNodeDOT)
 		require.NoError(t, err)
 		vars := map[string]any{"x": 1}
 
 		// Act
-		resp, err := executor.Process(context.Background(), graph, vars)
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, map[string]any{"done": true, "x": 1}, resp.Output)
+		assert.Equal(t, "ghost", resp.Node)
+		assert.Equal(t, TerminationUndeclaredNode, resp.Termination)
+	})
+	t.Run("explain mode records visited nodes, edge outcomes and assignments", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), linearDOT)
+		require.NoError(t, err)
+		vars := map[string]any{"age": 15}
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{Explain: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []TraceStep{
+			{
+				Node: "start",
+				Edges: []EdgeEvaluation{
+					{To: "ok", Cond: "age>=18", Result: false},
+					{To: "no", Cond: "age<18", Result: true},
+				},
+			},
+			{Node: "no", Assigned: map[string]any{"approved": false}},
+		}, resp.Trace)
+	})
+	t.Run("priority decides between matching edges regardless of declaration order", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		dot := `digraph { start [result=""]; adult [result="tier=adult"]; senior [result="tier=senior"]; start -> adult [cond="age>=18"]; start -> senior [cond="age>=65", priority=1]; }`
+		graph, err := parser.Parse(context.Background(), dot)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, map[string]any{"age": 70}, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "senior", resp.Node)
+	})
+	t.Run("default edge is taken only when no sibling matches", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		dot := `digraph { start [result=""]; review [result="manual=true"]; ok [result="approved=true"]; start -> review [cond="else"]; start -> ok [cond="score>700"]; }`
+		graph, err := parser.Parse(context.Background(), dot)
+		require.NoError(t, err)
+
+		// Act
+		matched, err := executor.Process(context.Background(), graph, map[string]any{"score": 800}, ExecOptions{})
+		require.NoError(t, err)
+		fallback, err := executor.Process(context.Background(), graph, map[string]any{"score": 500}, ExecOptions{Explain: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "ok", matched.Node)
+		assert.Equal(t, "review", fallback.Node)
+		assert.Equal(t, []EdgeEvaluation{
+			{To: "ok", Cond: "score>700", Result: false},
+			{To: "review", Default: true, Result: true},
+		}, fallback.Trace[0].Edges)
+	})
+	t.Run("without explain no trace is returned", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), linearDOT)
+		require.NoError(t, err)
+		vars := map[string]any{"age": 20}
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, vars, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, resp.Trace)
+	})
+	t.Run("strict mode returns cycle error with cycle path", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		loopDOT := `digraph { start [result=""]; a [result=""]; b [result=""]; start -> a; a -> b; b -> a; }`
+		graph, err := parser.Parse(context.Background(), loopDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = executor.Process(context.Background(), graph, map[string]any{}, ExecOptions{Strict: true})
+
+		// Assert
+		require.ErrorIs(t, err, ErrCycleDetected)
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []string{"a", "b", "a"}, cycleErr.Path)
+		assert.Equal(t, "cycle detected: a -> b -> a", err.Error())
+	})
+	t.Run("strict mode without revisit succeeds", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), linearDOT)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, map[string]any{"age": 20}, ExecOptions{Strict: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Node)
+	})
+	t.Run("loop-aware mode revisits nodes until no edge matches", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), ladderDOT)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := executor.Process(context.Background(), graph, map[string]any{}, ExecOptions{Explain: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "end", resp.Node)
+		assert.Equal(t, true, resp.Output["escalated"])
+		assert.Len(t, resp.Trace, 7)
+	})
+	t.Run("loop-aware mode accumulates a counter initialised to zero", func(t *testing.T) {
+		// Arrange
+		dot := `digraph { graph [max_steps=20]; start [result="attempt=0, score=0"]; retry [result="attempt=attempt+1, score=score+50"]; done [result="passed=score >= 150"]; start -> retry; retry -> retry [cond="attempt < 3"]; retry -> done; }`
+		graph, err := NewDotParser(nil).Parse(context.Background(), dot)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := NewGraphExecutor(nil).Process(context.Background(), graph, map[string]any{}, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "done", resp.Node)
+		assert.Equal(t, map[string]any{"attempt": 3.0, "score": 150.0, "passed": true}, resp.Output)
+	})
+	t.Run("loop-aware mode fails when graph step budget is exhausted", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		loopDOT := `digraph { max_steps=5; start [result=""]; a [result=""]; start -> a; a -> a; }`
+		graph, err := parser.Parse(context.Background(), loopDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = executor.Process(context.Background(), graph, map[string]any{}, ExecOptions{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrStepBudgetExceeded)
+	})
+	t.Run("request step budget overrides graph budget", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), ladderDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = executor.Process(context.Background(), graph, map[string]any{}, ExecOptions{MaxSteps: 4})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrStepBudgetExceeded)
+	})
+	t.Run("request step budget cannot raise the graph budget", func(t *testing.T) {
+		// Arrange
+		loopDOT := `digraph { max_steps=5; start [result=""]; a [result=""]; start -> a; a -> a; }`
+		graph, err := NewDotParser(nil).Parse(context.Background(), loopDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = NewGraphExecutor(nil).Process(context.Background(), graph, map[string]any{}, ExecOptions{MaxSteps: 1000})
+
+		// Assert
+		require.ErrorIs(t, err, ErrStepBudgetExceeded)
+		assert.EqualError(t, err, "step budget exceeded: 5 steps")
+	})
+	t.Run("request step budget is capped at MaxStepBudget", func(t *testing.T) {
+		// Arrange
+		graph, err := NewDotParser(nil).Parse(context.Background(), cycleDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = NewGraphExecutor(nil).Process(context.Background(), graph, map[string]any{"x": 1.0}, ExecOptions{MaxSteps: math.MaxInt})
+
+		// Assert
+		require.ErrorIs(t, err, ErrStepBudgetExceeded)
+		assert.EqualError(t, err, fmt.Sprintf("step budget exceeded: %d steps", MaxStepBudget))
+	})
+	t.Run("request step budget enables loop mode on plain graph", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), cycleDOT)
+		require.NoError(t, err)
+
+		// Act
+		_, err = executor.Process(context.Background(), graph, map[string]any{"x": 1.0}, ExecOptions{MaxSteps: 3})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrStepBudgetExceeded)
+	})
+	t.Run("cancelled context returns ErrTimeout", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		executor := NewGraphExecutor(nil)
+		graph, err := parser.Parse(context.Background(), linearDOT)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Act
+		_, err = executor.Process(ctx, graph, map[string]any{"age": 20}, ExecOptions{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTimeout)
+		assert.ErrorIs(t, err, context.Canceled)
 	})
 }
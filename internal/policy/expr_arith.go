@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+)
+
+// Arithmetic follows this numeric model:
+//
+//   - Integer literals and Go integer values are int64; number literals with a fraction and
+//     every other number (including JSON numbers, decoded as float64) are float64.
+//   - + - * and % on two integers stay int64 and fail with ErrArithmeticOverflow on overflow;
+//     with any float64 operand they are computed in float64.
+//   - / always divides in float64, so 7 / 2 is 3.5.
+//   - / and % by zero fail with ErrDivisionByZero; a float64 result that is not finite fails
+//     with ErrArithmeticOverflow.
+//   - Comparisons follow the same split: two integers compare as int64, anything else as float64.
+
+func (e evaluator) arithmetic(n *arithmeticNode) (any, error) {
+	left, err := e.value(n.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.value(n.right)
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range []struct {
+		node  exprNode
+		value any
+	}{{n.left, left}, {n.right, right}} {
+		if t := typeOf(operand.value); t != TypeNumber {
+			return nil, e.errorAt(operand.node, fmt.Sprintf("%s is %s but %q needs two numbers", describeOperand(operand.node), t, n.op.String()))
+		}
+	}
+
+	l, lok := toInt(left)
+	r, rok := toInt(right)
+	if lok && rok && n.op != tokSlash {
+		result, err := intArithmetic(n.op, l, r)
+		if err != nil {
+			return nil, &ConditionError{Cond: e.src, Pos: n.pos, Msg: fmt.Sprintf("%v in %d %s %d", err, l, n.op, r), Err: err}
+		}
+		return result, nil
+	}
+
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	result, err := floatArithmetic(n.op, lf, rf)
+	if err != nil {
+		return nil, &ConditionError{Cond: e.src, Pos: n.pos, Msg: fmt.Sprintf("%v in %v %s %v", err, lf, n.op, rf), Err: err}
+	}
+	return result, nil
+}
+
+func intArithmetic(op tokenKind, l, r int64) (int64, error) {
+	switch op {
+	case tokPlus:
+		if (r > 0 && l > math.MaxInt64-r) || (r < 0 && l < math.MinInt64-r) {
+			return 0, ErrArithmeticOverflow
+		}
+		return l + r, nil
+	case tokMinus:
+		if (r < 0 && l > math.MaxInt64+r) || (r > 0 && l < math.MinInt64+r) {
+			return 0, ErrArithmeticOverflow
+		}
+		return l - r, nil
+	case tokStar:
+		if l == 0 || r == 0 {
+			return 0, nil
+		}
+		product := l * r
+		if product/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64) {
+			return 0, ErrArithmeticOverflow
+		}
+		return product, nil
+	case tokPercent:
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return l % r, nil
+	}
+	return 0, fmt.Errorf("unsupported operator %s", op)
+}
+
+func floatArithmetic(op tokenKind, l, r float64) (float64, error) {
+	var result float64
+	switch op {
+	case tokPlus:
+		result = l + r
+	case tokMinus:
+		result = l - r
+	case tokStar:
+		result = l * r
+	case tokSlash:
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		result = l / r
+	case tokPercent:
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		result = math.Mod(l, r)
+	default:
+		return 0, fmt.Errorf("unsupported operator %s", op)
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return 0, ErrArithmeticOverflow
+	}
+	return result, nil
+}
+
+// toInt returns value as int64 when it is a Go integer that fits.
+func toInt(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	}
+	return 0, false
+}
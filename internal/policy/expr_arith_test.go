@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArithmetic(t *testing.T) {
+	t.Run("conditions compare computed values", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			vars map[string]any
+			want bool
+		}{
+			{cond: "debt / income < 0.4", vars: map[string]any{"debt": 300.0, "income": 1000.0}, want: true},
+			{cond: "a + b * c == 7", vars: map[string]any{"a": 1, "b": 2, "c": 3}, want: true},
+			{cond: "(a + b) * c == 9", vars: map[string]any{"a": 1, "b": 2, "c": 3}, want: true},
+			{cond: "a - b - c == -4", vars: map[string]any{"a": 1, "b": 2, "c": 3}, want: true},
+			{cond: "7 / n == 3.5", vars: map[string]any{"n": 2}, want: true},
+			{cond: "n % 3 == 1", vars: map[string]any{"n": 10}, want: true},
+			{cond: "x % 2.5 == 0.5", vars: map[string]any{"x": 3.0}, want: true},
+			{cond: "-n < 0", vars: map[string]any{"n": 4}, want: true},
+			{cond: "len(name) * 2 > 5", vars: map[string]any{"name": "Ana"}, want: true},
+			{cond: "(a > 1) && a + 1 > 2", vars: map[string]any{"a": 2}, want: true},
+		}
+		for _, tc := range cases {
+			// Act
+			got, err := EvalCondition(tc.cond, tc.vars)
+
+			// Assert
+			require.NoError(t, err, tc.cond)
+			assert.Equal(t, tc.want, got, tc.cond)
+		}
+	})
+	t.Run("integers compare exactly beyond float64 precision", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			vars map[string]any
+			want bool
+		}{
+			{cond: "a == 9007199254740993", vars: map[string]any{"a": int64(9007199254740992)}, want: false},
+			{cond: "a != b", vars: map[string]any{"a": int64(math.MaxInt64), "b": int64(math.MaxInt64 - 1)}, want: true},
+			{cond: "a > b", vars: map[string]any{"a": int64(math.MaxInt64), "b": int64(math.MaxInt64 - 1)}, want: true},
+			{cond: "a <= 9007199254740992", vars: map[string]any{"a": int64(9007199254740993)}, want: false},
+			{cond: "a in [1, 9007199254740993]", vars: map[string]any{"a": int64(9007199254740992)}, want: false},
+			{cond: "a == 2", vars: map[string]any{"a": 2.0}, want: true},
+			{cond: "a < 2.5", vars: map[string]any{"a": 2}, want: true},
+		}
+		for _, tc := range cases {
+			// Act
+			got, err := EvalCondition(tc.cond, tc.vars)
+
+			// Assert
+			require.NoError(t, err, tc.cond)
+			assert.Equal(t, tc.want, got, tc.cond)
+		}
+	})
+	t.Run("integers stay int64 and floats stay float64", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			vars map[string]any
+			want any
+		}{
+			{cond: "a + 1", vars: map[string]any{"a": 2}, want: int64(3)},
+			{cond: "a + 1", vars: map[string]any{"a": 2.0}, want: 3.0},
+			{cond: "a * 1.5", vars: map[string]any{"a": 2}, want: 3.0},
+			{cond: "a / 2", vars: map[string]any{"a": 4}, want: 2.0},
+			{cond: "a % 4", vars: map[string]any{"a": int64(-7)}, want: int64(-3)},
+		}
+		for _, tc := range cases {
+			// Arrange
+			node, err := parseCondition(tc.cond + " == 0")
+			require.NoError(t, err)
+			sum := node.(*comparisonNode).left.(*arithmeticNode)
+
+			// Act
+			got, err := evaluator{src: tc.cond, vars: tc.vars}.arithmetic(sum)
+
+			// Assert
+			require.NoError(t, err, tc.cond)
+			assert.Equal(t, tc.want, got, tc.cond)
+		}
+	})
+	t.Run("typed evaluation errors", func(t *testing.T) {
+		cases := []struct {
+			name string
+			cond string
+			vars map[string]any
+			err  error
+			pos  int
+			msg  string
+		}{
+			{name: "float division by zero", cond: "debt / income < 0.4", vars: map[string]any{"debt": 100, "income": 0}, err: ErrDivisionByZero, pos: 5, msg: "division by zero in 100 / 0"},
+			{name: "integer remainder by zero", cond: "n % d == 0", vars: map[string]any{"n": 10, "d": 0}, err: ErrDivisionByZero, pos: 2, msg: "division by zero in 10 % 0"},
+			{name: "integer addition overflow", cond: "n + 1 > 0", vars: map[string]any{"n": int64(math.MaxInt64)}, err: ErrArithmeticOverflow, pos: 2, msg: "arithmetic overflow in 9223372036854775807 + 1"},
+			{name: "integer multiplication overflow", cond: "n * 2 > 0", vars: map[string]any{"n": int64(math.MinInt64)}, err: ErrArithmeticOverflow, pos: 2, msg: "arithmetic overflow in -9223372036854775808 * 2"},
+			{name: "integer negation overflow", cond: "-n > 0", vars: map[string]any{"n": int64(math.MinInt64)}, err: ErrArithmeticOverflow, pos: 0, msg: "arithmetic overflow in 0 - -9223372036854775808"},
+			{name: "float overflow", cond: "x * x > 0", vars: map[string]any{"x": math.MaxFloat64}, err: ErrArithmeticOverflow, pos: 2, msg: "arithmetic overflow in 1.7976931348623157e+308 * 1.7976931348623157e+308"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				got, err := EvalCondition(tc.cond, tc.vars)
+
+				// Assert
+				var condErr *ConditionError
+				require.ErrorAs(t, err, &condErr)
+				assert.ErrorIs(t, err, tc.err)
+				assert.ErrorIs(t, err, ErrInvalidCondition)
+				assert.Equal(t, tc.pos, condErr.Pos)
+				assert.Equal(t, tc.msg, condErr.Msg)
+				assert.False(t, got)
+			})
+		}
+	})
+	t.Run("invalid arithmetic is rejected", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			vars map[string]any
+			pos  int
+			msg  string
+		}{
+			{cond: `name + 1 > 0`, vars: map[string]any{"name": "Ana"}, pos: 0, msg: `variable name is string but "+" needs two numbers`},
+			{cond: `age + "1" > 0`, vars: map[string]any{"age": 1}, pos: 6, msg: `literal 1 is string but "+" needs two numbers`},
+			{cond: `contains(a, "b") * 2 > 0`, vars: map[string]any{"a": "b"}, pos: 0, msg: `contains() is bool but "*" needs two numbers`},
+			{cond: `age + 1`, vars: map[string]any{"age": 1}, pos: 7, msg: "expected comparison operator, found end of condition"},
+			{cond: `(age + 1) && age > 0`, vars: map[string]any{"age": 1}, pos: 10, msg: `expected comparison operator, found "&&"`},
+			{cond: `(age > 0 && (age + 1))`, vars: map[string]any{"age": 1}, pos: 17, msg: "arithmetic expression is not allowed as condition"},
+		}
+		for _, tc := range cases {
+			// Act
+			got, err := EvalCondition(tc.cond, tc.vars)
+
+			// Assert
+			var condErr *ConditionError
+			require.ErrorAs(t, err, &condErr, tc.cond)
+			assert.Equal(t, tc.pos, condErr.Pos, tc.cond)
+			assert.Equal(t, tc.msg, condErr.Msg, tc.cond)
+			assert.False(t, got)
+		}
+	})
+}
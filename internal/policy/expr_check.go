@@ -0,0 +1,154 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+)
+
+// exprContext is the position a node occupies: a boolean test, a value compared by an
+// operator, the right side of "in", or a function argument.
+type exprContext string
+
+const (
+	contextBoolean    exprContext = "condition"
+	contextValue      exprContext = "operand"
+	contextMembership exprContext = "right side of in"
+	contextArgument   exprContext = "function argument"
+	contextResult     exprContext = "result value"
+)
+
+// allowedNodes whitelists the node kinds accepted in each context; anything else is rejected
+// before a condition can be evaluated.
+var allowedNodes = map[exprContext]map[nodeKind]bool{
+	contextBoolean:    {nodeLogical: true, nodeComparison: true, nodeCall: true},
+	contextValue:      {nodeIdent: true, nodeLiteral: true, nodeCall: true, nodeArithmetic: true},
+	contextMembership: {nodeIdent: true, nodeList: true, nodeCall: true},
+	contextArgument:   {nodeIdent: true, nodeLiteral: true, nodeList: true, nodeCall: true, nodeArithmetic: true},
+	contextResult:     {nodeLogical: true, nodeComparison: true, nodeIdent: true, nodeLiteral: true, nodeList: true, nodeCall: true, nodeArithmetic: true},
+}
+
+// checkValue checks a computed result value, which may be any value or a full condition.
+func checkValue(src string, node exprNode, functions *FunctionRegistry) error {
+	c := checker{src: src, functions: functions}
+	return c.checkNode(node, contextResult)
+}
+
+// checkCondition walks a parsed condition, rejects any node outside the whitelist for its
+// context and resolves function calls against functions, checking arity and the types known
+// before evaluation.
+func checkCondition(src string, node exprNode, functions *FunctionRegistry) error {
+	c := checker{src: src, functions: functions}
+	return c.checkNode(node, contextBoolean)
+}
+
+type checker struct {
+	src       string
+	functions *FunctionRegistry
+}
+
+func (c checker) checkNode(node exprNode, ctx exprContext) error {
+	src := c.src
+	if !allowedNodes[ctx][node.kind()] {
+		return &ConditionError{Cond: src, Pos: node.position(), Msg: fmt.Sprintf("%s is not allowed as %s", node.kind(), ctx)}
+	}
+	switch n := node.(type) {
+	case *logicalNode:
+		if err := c.checkNode(n.left, contextBoolean); err != nil {
+			return err
+		}
+		return c.checkNode(n.right, contextBoolean)
+	case *comparisonNode:
+		if err := c.checkNode(n.left, contextValue); err != nil {
+			return err
+		}
+		if n.op == tokIn {
+			return c.checkNode(n.right, contextMembership)
+		}
+		return c.checkNode(n.right, contextValue)
+	case *arithmeticNode:
+		for _, operand := range []exprNode{n.left, n.right} {
+			if err := c.checkNode(operand, contextValue); err != nil {
+				return err
+			}
+			if t, known := staticType(operand); known && t != TypeNumber {
+				return &ConditionError{Cond: src, Pos: operand.position(), Msg: fmt.Sprintf("%s is %s but %q needs two numbers", describeOperand(operand), t, n.op.String())}
+			}
+		}
+	case *callNode:
+		return c.checkCall(n, ctx)
+	}
+	return nil
+}
+
+func (c checker) checkCall(n *callNode, ctx exprContext) error {
+	src := c.src
+	fn, ok := c.functions.lookup(n.name)
+	if !ok {
+		return &ConditionError{Cond: src, Pos: n.pos, Msg: fmt.Sprintf("unknown function %s", n.name)}
+	}
+	if len(n.args) != len(fn.params) {
+		return &ConditionError{Cond: src, Pos: n.pos, Msg: fmt.Sprintf("%s takes %d arguments, got %d", fn.name, len(fn.params), len(n.args))}
+	}
+	if ctx == contextBoolean && fn.result != TypeBool {
+		return &ConditionError{Cond: src, Pos: n.pos, Msg: fmt.Sprintf("%s returns %s, not a condition", fn.name, fn.result)}
+	}
+	if ctx == contextMembership && fn.result != TypeList {
+		return &ConditionError{Cond: src, Pos: n.pos, Msg: fmt.Sprintf("%s returns %s, not a list", fn.name, fn.result)}
+	}
+	for i, arg := range n.args {
+		if err := c.checkNode(arg, contextArgument); err != nil {
+			return err
+		}
+		if t, known := staticType(arg); known && !fn.accepts(i, t) {
+			return &ConditionError{Cond: src, Pos: arg.position(), Msg: fmt.Sprintf("argument %d of %s must be %s, got %s", i+1, fn.name, fn.describeParam(i), t)}
+		}
+	}
+	n.fn, n.impl = fn, fn.call
+	if fn.prepare != nil {
+		impl, err := fn.prepare(n.args)
+		if err != nil {
+			return &ConditionError{Cond: src, Pos: n.pos, Msg: err.Error()}
+		}
+		n.impl = impl
+	}
+	return nil
+}
+
+// hasCall reports whether node calls a function anywhere.
+func hasCall(node exprNode) bool {
+	if _, ok := node.(*callNode); ok {
+		return true
+	}
+	return slices.ContainsFunc(children(node), hasCall)
+}
+
+func children(node exprNode) []exprNode {
+	switch n := node.(type) {
+	case *logicalNode:
+		return []exprNode{n.left, n.right}
+	case *comparisonNode:
+		return []exprNode{n.left, n.right}
+	case *arithmeticNode:
+		return []exprNode{n.left, n.right}
+	case *callNode:
+		return n.args
+	}
+	return nil
+}
+
+// staticType returns the type of node when it is known before evaluation.
+func staticType(node exprNode) (ValueType, bool) {
+	switch n := node.(type) {
+	case *literalNode:
+		return typeOf(n.value), true
+	case *listNode:
+		return TypeList, true
+	case *arithmeticNode:
+		return TypeNumber, true
+	case *callNode:
+		if n.fn != nil {
+			return n.fn.result, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,298 @@
+package policy
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+)
+
+// Conditions are evaluated with these rules:
+//
+//   - && and || short-circuit: the right side is not evaluated once the left decides the result.
+//   - A variable that is not set is an error naming it, but only when evaluation reaches it.
+//   - == and != compare numbers by value whatever their Go type; operands of different types
+//     are never equal. Two integers are compared exactly as int64, any other pair of numbers
+//     as float64, and the same holds for >, <, >= and <=.
+//   - >, <, >= and <= need two numbers or two strings (compared byte-wise).
+//   - "in" is true when the left value equals (as ==) an item of the list on the right.
+//   - Function arguments are type-checked against the function's parameters before the call.
+
+type evaluator struct {
+	src  string
+	vars map[string]any
+}
+
+func (e evaluator) evalBool(node exprNode) (bool, error) {
+	switch n := node.(type) {
+	case *logicalNode:
+		left, err := e.evalBool(n.left)
+		if err != nil {
+			return false, err
+		}
+		if n.op == tokOr && left || n.op == tokAnd && !left {
+			return left, nil
+		}
+		return e.evalBool(n.right)
+	case *comparisonNode:
+		return e.compare(n)
+	case *callNode:
+		result, err := e.call(n)
+		if err != nil {
+			return false, err
+		}
+		b, ok := result.(bool)
+		if !ok {
+			return false, e.errorAt(n, fmt.Sprintf("%s returned %s, not bool", n.name, typeOf(result)))
+		}
+		return b, nil
+	}
+	return false, e.errorAt(node, fmt.Sprintf("%s is not a condition", node.kind()))
+}
+
+func (e evaluator) value(node exprNode) (any, error) {
+	switch n := node.(type) {
+	case *identNode:
+		value, msg := lookupPath(e.vars, n.path)
+		if msg != "" {
+			return nil, e.errorAt(n, msg)
+		}
+		return value, nil
+	case *literalNode:
+		return n.value, nil
+	case *listNode:
+		return n.items, nil
+	case *callNode:
+		return e.call(n)
+	case *arithmeticNode:
+		return e.arithmetic(n)
+	case *logicalNode, *comparisonNode:
+		return e.evalBool(n)
+	}
+	return nil, e.errorAt(node, fmt.Sprintf("%s is not a value", node.kind()))
+}
+
+func (e evaluator) call(n *callNode) (any, error) {
+	if n.impl == nil {
+		return nil, e.errorAt(n, fmt.Sprintf("unknown function %s", n.name))
+	}
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, err := e.value(arg)
+		if err != nil {
+			return nil, err
+		}
+		if t := typeOf(value); !n.fn.accepts(i, t) {
+			return nil, e.errorAt(arg, fmt.Sprintf("argument %d of %s must be %s, got %s", i+1, n.name, n.fn.describeParam(i), t))
+		}
+		args[i] = value
+	}
+	result, err := n.impl(args)
+	if err != nil {
+		return nil, e.errorAt(n, fmt.Sprintf("%s: %v", n.name, err))
+	}
+	if t := typeOf(result); t != n.fn.result {
+		return nil, e.errorAt(n, fmt.Sprintf("%s returned %s, declared %s", n.name, t, n.fn.result))
+	}
+	return result, nil
+}
+
+func (e evaluator) member(n *comparisonNode, value, list any) (bool, error) {
+	items, ok := toList(list)
+	if !ok {
+		return false, e.errorAt(n.right, fmt.Sprintf("%s is %s but in needs a list", describeOperand(n.right), typeOf(list)))
+	}
+	for _, item := range items {
+		if valuesEqual(value, item) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (e evaluator) compare(n *comparisonNode) (bool, error) {
+	left, err := e.value(n.left)
+	if err != nil {
+		return false, err
+	}
+	right, err := e.value(n.right)
+	if err != nil {
+		return false, err
+	}
+	switch n.op {
+	case tokEq:
+		return valuesEqual(left, right), nil
+	case tokNe:
+		return !valuesEqual(left, right), nil
+	case tokIn:
+		return e.member(n, left, right)
+	}
+	if order, ok := compareNumbers(left, right); ok {
+		return orderHolds(n.op, order), nil
+	}
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return orderHolds(n.op, compareStrings(l, r)), nil
+		}
+	}
+	return false, e.orderingError(n, left, right)
+}
+
+// orderingError names the operand that keeps an ordering comparison from type-checking.
+func (e evaluator) orderingError(n *comparisonNode, left, right any) error {
+	leftType, rightType := typeOf(left), typeOf(right)
+	culprit, culpritType, otherType := n.left, leftType, rightType
+	if leftType == TypeNumber || leftType == TypeString {
+		culprit, culpritType, otherType = n.right, rightType, leftType
+	}
+	msg := fmt.Sprintf("%s is %s but %q needs two numbers or two strings (other operand is %s)", describeOperand(culprit), culpritType, n.op.String(), otherType)
+	return e.errorAt(culprit, msg)
+}
+
+func (e evaluator) errorAt(node exprNode, msg string) error {
+	return &ConditionError{Cond: e.src, Pos: node.position(), Msg: msg}
+}
+
+func orderHolds(op tokenKind, cmp int) bool {
+	switch op {
+	case tokGt:
+		return cmp > 0
+	case tokLt:
+		return cmp < 0
+	case tokGe:
+		return cmp >= 0
+	case tokLe:
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareNumbers orders two numbers, as int64 when both are integers so values beyond 2^53
+// stay distinct, and as float64 otherwise. It reports false unless both are numbers.
+func compareNumbers(a, b any) (int, bool) {
+	if l, ok := toInt(a); ok {
+		if r, ok := toInt(b); ok {
+			return cmp.Compare(l, r), true
+		}
+	}
+	l, lok := toFloat(a)
+	r, rok := toFloat(b)
+	if !lok || !rok {
+		return 0, false
+	}
+	return compareFloats(l, r), true
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func valuesEqual(a, b any) bool {
+	if l, ok := toInt(a); ok {
+		if r, ok := toInt(b); ok {
+			return l == r
+		}
+	}
+	if l, ok := toFloat(a); ok {
+		r, ok := toFloat(b)
+		return ok && l == r
+	}
+	if typeOf(a) != typeOf(b) {
+		return false
+	}
+	if t := typeOf(a); t == TypeList || t == TypeObject {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func typeOf(value any) ValueType {
+	if _, ok := toFloat(value); ok {
+		return TypeNumber
+	}
+	switch value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBool
+	case string:
+		return TypeString
+	}
+	if _, ok := toList(value); ok {
+		return TypeList
+	}
+	return TypeObject
+}
+
+// toList returns the items of a slice or array value, such as a JSON array decoded as []any.
+func toList(value any) ([]any, bool) {
+	if items, ok := value.([]any); ok {
+		return items, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+func describeOperand(node exprNode) string {
+	switch n := node.(type) {
+	case *identNode:
+		return "variable " + n.name
+	case *literalNode:
+		return fmt.Sprintf("literal %v", n.value)
+	case *callNode:
+		return n.name + "()"
+	}
+	return string(node.kind())
+}
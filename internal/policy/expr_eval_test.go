@@ -0,0 +1,265 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator(t *testing.T) {
+	t.Run("|| short-circuits past a missing variable", func(t *testing.T) {
+		// Arrange
+		cond := "age>=18 || vip==true"
+		vars := map[string]any{"age": 20}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("&& short-circuits past a missing variable inside a group", func(t *testing.T) {
+		// Arrange
+		cond := "(age>=18 && score>700) || vip==true"
+		vars := map[string]any{"age": 16, "vip": false}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, got)
+	})
+	t.Run("numbers of different Go types compare by value", func(t *testing.T) {
+		// Arrange
+		cond := "a == b && a >= c"
+		vars := map[string]any{"a": int64(3), "b": 3.0, "c": uint8(2)}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("values of different types are not equal", func(t *testing.T) {
+		// Arrange
+		cond := `x == "1" || x != 1`
+		vars := map[string]any{"x": 1}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, got)
+	})
+	t.Run("strings are ordered byte-wise", func(t *testing.T) {
+		// Arrange
+		cond := `tier < "gold"`
+		vars := map[string]any{"tier": "bronze"}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("null input equals nothing but null", func(t *testing.T) {
+		// Arrange
+		cond := "a == b && a != 0"
+		vars := map[string]any{"a": nil, "b": nil}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, got)
+	})
+	t.Run("ordering a bool reports the operand", func(t *testing.T) {
+		// Arrange
+		cond := "active > 1"
+		vars := map[string]any{"active": true}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, 0, condErr.Pos)
+		assert.Equal(t, `variable active is bool but ">" needs two numbers or two strings (other operand is number)`, condErr.Msg)
+		assert.False(t, got)
+	})
+}
+
+func TestCheckCondition(t *testing.T) {
+	t.Run("parsed conditions pass the whitelist", func(t *testing.T) {
+		// Arrange
+		node, err := parseCondition(`(a == 1 || b != "x") && c >= d`)
+		require.NoError(t, err)
+
+		// Act
+		err = checkCondition("", node, builtinRegistry)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+	t.Run("value node in boolean position is rejected", func(t *testing.T) {
+		// Arrange
+		node := &logicalNode{op: tokAnd, left: &identNode{name: "a"}, right: &identNode{name: "b", pos: 5}}
+
+		// Act
+		err := checkCondition("a && b", node, builtinRegistry)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "variable is not allowed as condition", condErr.Msg)
+	})
+	t.Run("comparison in operand position is rejected", func(t *testing.T) {
+		// Arrange
+		inner := &comparisonNode{op: tokEq, left: &identNode{name: "a"}, right: &literalNode{value: 1.0}, pos: 2}
+		node := &comparisonNode{op: tokEq, left: inner, right: &literalNode{value: true}}
+
+		// Act
+		err := checkCondition("a == 1 == true", node, builtinRegistry)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, 2, condErr.Pos)
+		assert.Equal(t, "comparison is not allowed as operand", condErr.Msg)
+	})
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	cases := []struct {
+		name string
+		cond string
+		vars map[string]any
+		want bool
+	}{
+		{name: "in list literal", cond: `state in ["SP", "RJ"]`, vars: map[string]any{"state": "RJ"}, want: true},
+		{name: "in list literal misses", cond: `state in ["SP", "RJ"]`, vars: map[string]any{"state": "MG"}, want: false},
+		{name: "in numeric list", cond: "code in [1, 2, 3]", vars: map[string]any{"code": 2}, want: true},
+		{name: "in list variable", cond: "state in allowed", vars: map[string]any{"state": "SP", "allowed": []any{"SP"}}, want: true},
+		{name: "contains", cond: `contains(email, "@corp.com")`, vars: map[string]any{"email": "ana@corp.com"}, want: true},
+		{name: "startsWith", cond: `startsWith(cpf, "123") == false`, vars: map[string]any{"cpf": "98765"}, want: true},
+		{name: "len of string counts characters", cond: "len(name) == 4", vars: map[string]any{"name": "João"}, want: true},
+		{name: "len of list", cond: "len(tags) >= 2", vars: map[string]any{"tags": []any{"a", "b"}}, want: true},
+		{name: "matches", cond: `matches(zip, "^[0-9]{5}-[0-9]{3}$")`, vars: map[string]any{"zip": "01310-100"}, want: true},
+		{name: "matches misses", cond: `matches(zip, "^[0-9]{5}-[0-9]{3}$")`, vars: map[string]any{"zip": "0131"}, want: false},
+		{name: "combined with logical operators", cond: `age >= 18 && (state in ["SP"] || contains(name, "a"))`, vars: map[string]any{"age": 20, "state": "RJ", "name": "Ana"}, want: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			got, err := EvalCondition(tc.cond, tc.vars)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestBuiltinFunctionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		cond string
+		vars map[string]any
+		pos  int
+		msg  string
+	}{
+		{name: "unknown function", cond: "isAdult(age) == true", pos: 0, msg: "unknown function isAdult"},
+		{name: "wrong arity", cond: `contains(name)`, pos: 0, msg: "contains takes 2 arguments, got 1"},
+		{name: "literal argument of the wrong type", cond: "len(5) > 1", pos: 4, msg: "argument 1 of len must be string or list, got number"},
+		{name: "non-bool function as condition", cond: "len(name)", pos: 0, msg: "len returns number, not a condition"},
+		{name: "pattern must be a literal", cond: "matches(zip, pattern)", pos: 0, msg: "matches needs a string literal pattern"},
+		{name: "invalid pattern", cond: `matches(zip, "[")`, pos: 0, msg: "invalid pattern \"[\": error parsing regexp: missing closing ]: `[`"},
+		{name: "variable argument of the wrong type", cond: "len(age) > 1", vars: map[string]any{"age": 30}, pos: 4, msg: "argument 1 of len must be string or list, got number"},
+		{name: "in needs a list", cond: "state in allowed", vars: map[string]any{"state": "SP", "allowed": "SP"}, pos: 9, msg: "variable allowed is string but in needs a list"},
+		{name: "list literal on the left", cond: `["SP"] == state`, pos: 0, msg: `expected variable or literal, found "["`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			got, err := EvalCondition(tc.cond, tc.vars)
+
+			// Assert
+			var condErr *ConditionError
+			require.ErrorAs(t, err, &condErr)
+			assert.Equal(t, tc.pos, condErr.Pos)
+			assert.Equal(t, tc.msg, condErr.Msg)
+			assert.False(t, got)
+		})
+	}
+}
+
+func TestNestedVariables(t *testing.T) {
+	vars := map[string]any{
+		"customer": map[string]any{"address": map[string]any{"state": "SP"}, "tags": []any{"vip"}},
+		"items":    []any{map[string]any{"price": 12.5}, map[string]any{"price": 3}},
+		"name":     "Ana",
+	}
+
+	t.Run("dotted paths and indices resolve", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			want bool
+		}{
+			{cond: `customer.address.state == "SP"`, want: true},
+			{cond: "items[0].price > 10", want: true},
+			{cond: "items[1].price > 10", want: false},
+			{cond: `customer.tags[0] in ["vip", "gold"]`, want: true},
+			{cond: "len(items) == 2 && items[1].price <= items[0].price", want: true},
+		}
+		for _, tc := range cases {
+			// Act
+			got, err := EvalCondition(tc.cond, vars)
+
+			// Assert
+			require.NoError(t, err, tc.cond)
+			assert.Equal(t, tc.want, got, tc.cond)
+		}
+	})
+	t.Run("unreachable paths name the failing part", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			msg  string
+		}{
+			{cond: `customer.address.city == "SP"`, msg: "variable customer.address.city is not set"},
+			{cond: "items[5].price > 10", msg: "variable items has 2 items, index 5 is out of range"},
+			{cond: `name.first == "Ana"`, msg: "variable name is string, not an object"},
+			{cond: `customer.address[0] == "SP"`, msg: "variable customer.address is object, not a list"},
+		}
+		for _, tc := range cases {
+			// Act
+			got, err := EvalCondition(tc.cond, vars)
+
+			// Assert
+			var condErr *ConditionError
+			require.ErrorAs(t, err, &condErr, tc.cond)
+			assert.Equal(t, tc.msg, condErr.Msg, tc.cond)
+			assert.Equal(t, 0, condErr.Pos, tc.cond)
+			assert.False(t, got)
+		}
+	})
+	t.Run("maps with string keys of any value type resolve", func(t *testing.T) {
+		// Arrange
+		cond := `labels.team == "risk"`
+		vars := map[string]any{"labels": map[string]string{"team": "risk"}}
+
+		// Act
+		got, err := EvalCondition(cond, vars)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, got)
+	})
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// funcImpl computes a function's result; its arguments have already been type-checked.
+type funcImpl func(args []any) (any, error)
+
+// function describes a condition function: each parameter lists the value types it accepts.
+// prepare, when set, runs once per call site at compile time (e.g. to compile a literal
+// pattern) and returns the implementation used for that call.
+type function struct {
+	name    string
+	params  [][]ValueType
+	result  ValueType
+	call    funcImpl
+	prepare func(args []exprNode) (funcImpl, error)
+}
+
+// builtinFunctions are the side-effect-free functions available to every condition.
+var builtinFunctions = map[string]*function{
+	"contains": {
+		name:   "contains",
+		params: [][]ValueType{{TypeString}, {TypeString}},
+		result: TypeBool,
+		call: func(args []any) (any, error) {
+			return strings.Contains(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"startsWith": {
+		name:   "startsWith",
+		params: [][]ValueType{{TypeString}, {TypeString}},
+		result: TypeBool,
+		call: func(args []any) (any, error) {
+			return strings.HasPrefix(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"len": {
+		name:   "len",
+		params: [][]ValueType{{TypeString, TypeList}},
+		result: TypeNumber,
+		call: func(args []any) (any, error) {
+			if s, ok := args[0].(string); ok {
+				return float64(utf8.RuneCountInString(s)), nil
+			}
+			return float64(reflect.ValueOf(args[0]).Len()), nil
+		},
+	},
+	"matches": {
+		name:    "matches",
+		params:  [][]ValueType{{TypeString}, {TypeString}},
+		result:  TypeBool,
+		prepare: prepareMatches,
+	},
+}
+
+// prepareMatches compiles the pattern once; it must be a string literal so a policy cannot
+// build expressions from input at evaluation time.
+func prepareMatches(args []exprNode) (funcImpl, error) {
+	lit, ok := args[1].(*literalNode)
+	if !ok {
+		return nil, fmt.Errorf("matches needs a string literal pattern")
+	}
+	pattern, _ := lit.value.(string)
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return func(args []any) (any, error) {
+		return re.MatchString(args[0].(string)), nil
+	}, nil
+}
+
+func (f *function) accepts(param int, t ValueType) bool {
+	for _, allowed := range f.params[param] {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *function) describeParam(param int) string {
+	names := make([]string, len(f.params[param]))
+	for i, t := range f.params[param] {
+		names[i] = string(t)
+	}
+	return strings.Join(names, " or ")
+}
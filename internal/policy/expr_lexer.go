@@ -0,0 +1,186 @@
+package policy
+
+import "fmt"
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokTrue
+	tokFalse
+	tokEq
+	tokNe
+	tokGe
+	tokLe
+	tokGt
+	tokLt
+	tokAnd
+	tokOr
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+	tokIn
+	tokDot
+	tokPlus
+	tokMinus
+	tokStar
+	tokSlash
+	tokPercent
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of condition",
+	tokIdent:    "variable",
+	tokNumber:   "number",
+	tokString:   "string",
+	tokTrue:     "true",
+	tokFalse:    "false",
+	tokEq:       "==",
+	tokNe:       "!=",
+	tokGe:       ">=",
+	tokLe:       "<=",
+	tokGt:       ">",
+	tokLt:       "<",
+	tokAnd:      "&&",
+	tokOr:       "||",
+	tokLParen:   "(",
+	tokRParen:   ")",
+	tokLBracket: "[",
+	tokRBracket: "]",
+	tokComma:    ",",
+	tokIn:       "in",
+	tokDot:      ".",
+	tokPlus:     "+",
+	tokMinus:    "-",
+	tokStar:     "*",
+	tokSlash:    "/",
+	tokPercent:  "%",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+// token is one lexeme of a condition; pos is its byte offset in the source.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var twoCharOperators = map[string]tokenKind{
+	"==": tokEq,
+	"!=": tokNe,
+	">=": tokGe,
+	"<=": tokLe,
+	"&&": tokAnd,
+	"||": tokOr,
+}
+
+var oneCharOperators = map[byte]tokenKind{
+	'>': tokGt,
+	'<': tokLt,
+	'(': tokLParen,
+	')': tokRParen,
+	'[': tokLBracket,
+	']': tokRBracket,
+	',': tokComma,
+	'.': tokDot,
+	'+': tokPlus,
+	'-': tokMinus,
+	'*': tokStar,
+	'/': tokSlash,
+	'%': tokPercent,
+}
+
+// lexCondition splits src into tokens, always ending with tokEOF.
+func lexCondition(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, identOrKeyword(src[start:i], start))
+		case isDigit(c):
+			start := i
+			i = scanNumber(src, i)
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start})
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				i++
+			}
+			if i >= len(src) {
+				return nil, &ConditionError{Cond: src, Pos: start, Msg: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: src[start+1 : i-1], pos: start})
+		default:
+			if i+1 < len(src) {
+				if kind, ok := twoCharOperators[src[i:i+2]]; ok {
+					tokens = append(tokens, token{kind: kind, text: src[i : i+2], pos: i})
+					i += 2
+					continue
+				}
+			}
+			kind, ok := oneCharOperators[c]
+			if !ok {
+				return nil, &ConditionError{Cond: src, Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			tokens = append(tokens, token{kind: kind, text: src[i : i+1], pos: i})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func identOrKeyword(word string, pos int) token {
+	switch word {
+	case "true":
+		return token{kind: tokTrue, text: word, pos: pos}
+	case "false":
+		return token{kind: tokFalse, text: word, pos: pos}
+	case "in":
+		return token{kind: tokIn, text: word, pos: pos}
+	}
+	return token{kind: tokIdent, text: word, pos: pos}
+}
+
+// scanNumber returns the end of the number starting at i: digits and an optional fraction.
+// A leading minus is lexed as its own token and folded by the parser.
+func scanNumber(src string, i int) int {
+	for i < len(src) && isDigit(src[i]) {
+		i++
+	}
+	if i+1 < len(src) && src[i] == '.' && isDigit(src[i+1]) {
+		i++
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,477 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Conditions are parsed with this grammar; && binds tighter than ||, arithmetic binds tighter
+// than comparisons, * / % bind tighter than + -, and parentheses group:
+//
+//	condition  = or
+//	or         = and { "||" and }
+//	and        = comparison { "&&" comparison }
+//	comparison = sum [ ( "==" | "!=" | ">=" | "<=" | ">" | "<" ) sum | "in" ( list | sum ) ]
+//	sum        = term { ( "+" | "-" ) term }
+//	term       = unary { ( "*" | "/" | "%" ) unary }
+//	unary      = "-" unary | primary
+//	primary    = "(" or ")" | operand
+//	operand    = variable | literal | call
+//	variable   = IDENT { "." IDENT | "[" INDEX "]" }
+//	call       = IDENT "(" [ argument { "," argument } ] ")"
+//	argument   = sum | list
+//	list       = "[" [ [ "-" ] literal { "," [ "-" ] literal } ] "]"
+//	literal    = NUMBER | STRING | "true" | "false"
+//
+// A sum without a comparison must be a call, a parenthesized condition or a parenthesized
+// value; the node whitelist in checkCondition then decides which node may appear where. At
+// least one operand of a comparison must not be a literal, and a call standing alone as a
+// condition must return a bool. Functions are resolved and type-checked by checkCondition.
+type (
+	exprNode interface {
+		position() int
+		kind() nodeKind
+	}
+
+	logicalNode struct {
+		op          tokenKind
+		left, right exprNode
+		pos         int
+	}
+
+	comparisonNode struct {
+		op          tokenKind
+		left, right exprNode
+		pos         int
+	}
+
+	// arithmeticNode applies + - * / %; unary minus is parsed as 0 - operand.
+	arithmeticNode struct {
+		op          tokenKind
+		left, right exprNode
+		pos         int
+	}
+
+	// identNode is a variable reference; name is its source form, e.g. items[0].price.
+	identNode struct {
+		name string
+		path []pathSegment
+		pos  int
+	}
+
+	literalNode struct {
+		value any
+		pos   int
+	}
+
+	listNode struct {
+		items []any
+		pos   int
+	}
+
+	callNode struct {
+		name string
+		args []exprNode
+		pos  int
+		// fn and impl are resolved by checkCondition.
+		fn   *function
+		impl funcImpl
+	}
+)
+
+func (n *logicalNode) position() int    { return n.pos }
+func (n *comparisonNode) position() int { return n.pos }
+func (n *arithmeticNode) position() int { return n.pos }
+func (n *identNode) position() int      { return n.pos }
+func (n *literalNode) position() int    { return n.pos }
+func (n *listNode) position() int       { return n.pos }
+func (n *callNode) position() int       { return n.pos }
+
+// nodeKind names a syntax tree node type; checkCondition uses it to whitelist where each may appear.
+type nodeKind string
+
+const (
+	nodeLogical    nodeKind = "logical expression"
+	nodeComparison nodeKind = "comparison"
+	nodeArithmetic nodeKind = "arithmetic expression"
+	nodeIdent      nodeKind = "variable"
+	nodeLiteral    nodeKind = "literal"
+	nodeList       nodeKind = "list"
+	nodeCall       nodeKind = "function call"
+)
+
+func (n *logicalNode) kind() nodeKind    { return nodeLogical }
+func (n *comparisonNode) kind() nodeKind { return nodeComparison }
+func (n *arithmeticNode) kind() nodeKind { return nodeArithmetic }
+func (n *identNode) kind() nodeKind      { return nodeIdent }
+func (n *literalNode) kind() nodeKind    { return nodeLiteral }
+func (n *listNode) kind() nodeKind       { return nodeList }
+func (n *callNode) kind() nodeKind       { return nodeCall }
+
+var comparisonOperators = map[tokenKind]bool{
+	tokEq: true, tokNe: true, tokGe: true, tokLe: true, tokGt: true, tokLt: true,
+}
+
+var (
+	sumOperators  = map[tokenKind]bool{tokPlus: true, tokMinus: true}
+	termOperators = map[tokenKind]bool{tokStar: true, tokSlash: true, tokPercent: true}
+)
+
+type exprParser struct {
+	src    string
+	tokens []token
+	next   int
+	// values accepts a bare value where a comparison is expected, as in a computed result.
+	values bool
+}
+
+// parseCondition parses a non-empty condition into its syntax tree.
+func parseCondition(src string) (exprNode, error) {
+	return parseExpression(src, false)
+}
+
+// parseValue parses a computed result value, which is any value or a full condition.
+func parseValue(src string) (exprNode, error) {
+	return parseExpression(src, true)
+}
+
+func parseExpression(src string, values bool) (exprNode, error) {
+	tokens, err := lexCondition(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{src: src, tokens: tokens, values: values}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", describe(tok)))
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		op := p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: tokOr, left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		op := p.advance()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: tokAnd, left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	var right exprNode
+	switch {
+	case comparisonOperators[op.kind]:
+		p.advance()
+		right, err = p.parseSum()
+	case op.kind == tokIn:
+		p.advance()
+		right, err = p.parseMembershipTarget()
+	default:
+		switch left.(type) {
+		case *callNode, *logicalNode, *comparisonNode:
+			return left, nil
+		}
+		if op.kind == tokRParen || p.values {
+			// A parenthesized value such as (a + b); checkCondition rejects it as a condition.
+			return left, nil
+		}
+		p.advance()
+		return nil, p.errorAt(op, fmt.Sprintf("expected comparison operator, found %s", describe(op)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isConstant(left) && isConstant(right) {
+		return nil, &ConditionError{Cond: p.src, Pos: op.pos, Msg: "comparison between two literals; one side must be a variable"}
+	}
+	return &comparisonNode{op: op.kind, left: left, right: right, pos: op.pos}, nil
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for sumOperators[p.peek().kind] {
+		op := p.advance()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op.kind, left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseTerm() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for termOperators[p.peek().kind] {
+		op := p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op.kind, left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+// parseUnary folds a minus in front of a number into a negative literal, so -1 stays a literal.
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek().kind != tokMinus {
+		return p.parsePrimary()
+	}
+	minus := p.advance()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if lit, ok := operand.(*literalNode); ok {
+		switch v := lit.value.(type) {
+		case int64:
+			if v != math.MinInt64 {
+				return &literalNode{value: -v, pos: minus.pos}, nil
+			}
+		case float64:
+			return &literalNode{value: -v, pos: minus.pos}, nil
+		}
+	}
+	zero := &literalNode{value: int64(0), pos: minus.pos}
+	return &arithmeticNode{op: tokMinus, left: zero, right: operand, pos: minus.pos}, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.peek().kind != tokLParen {
+		return p.parseOperand()
+	}
+	open := p.advance()
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokRParen {
+		return nil, p.errorAt(tok, fmt.Sprintf("expected ) to close ( at column %d, found %s", open.pos+1, describe(tok)))
+	}
+	p.advance()
+	return inner, nil
+}
+
+func (p *exprParser) parseMembershipTarget() (exprNode, error) {
+	if p.peek().kind == tokLBracket {
+		return p.parseList()
+	}
+	return p.parseSum()
+}
+
+func (p *exprParser) parseOperand() (exprNode, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		return p.parseVariable(tok)
+	case tokNumber, tokString, tokTrue, tokFalse:
+		return p.literal(tok)
+	default:
+		return nil, p.errorAt(tok, fmt.Sprintf("expected variable or literal, found %s", describe(tok)))
+	}
+}
+
+func (p *exprParser) parseVariable(root token) (exprNode, error) {
+	path := []pathSegment{{key: root.text}}
+	for {
+		switch p.peek().kind {
+		case tokDot:
+			p.advance()
+			field := p.advance()
+			if field.kind != tokIdent {
+				return nil, p.errorAt(field, fmt.Sprintf("expected field name after ., found %s", describe(field)))
+			}
+			path = append(path, pathSegment{key: field.text})
+		case tokLBracket:
+			open := p.advance()
+			index := p.advance()
+			n, err := strconv.Atoi(index.text)
+			if index.kind != tokNumber || err != nil || n < 0 {
+				return nil, p.errorAt(index, fmt.Sprintf("expected list index, found %s", describe(index)))
+			}
+			if tok := p.advance(); tok.kind != tokRBracket {
+				return nil, p.errorAt(tok, fmt.Sprintf("expected ] to close [ at column %d, found %s", open.pos+1, describe(tok)))
+			}
+			path = append(path, pathSegment{index: n, isIndex: true})
+		default:
+			return &identNode{name: pathName(path), path: path, pos: root.pos}, nil
+		}
+	}
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	open := p.advance()
+	call := &callNode{name: name.text, pos: name.pos}
+	if p.peek().kind == tokRParen {
+		p.advance()
+		return call, nil
+	}
+	for {
+		var arg exprNode
+		var err error
+		if p.peek().kind == tokLBracket {
+			arg, err = p.parseList()
+		} else {
+			arg, err = p.parseSum()
+		}
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		tok := p.advance()
+		if tok.kind == tokRParen {
+			return call, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected , or ) to close ( at column %d, found %s", open.pos+1, describe(tok)))
+		}
+	}
+}
+
+func (p *exprParser) parseList() (exprNode, error) {
+	open := p.advance()
+	list := &listNode{pos: open.pos}
+	if p.peek().kind == tokRBracket {
+		p.advance()
+		return list, nil
+	}
+	for {
+		tok := p.advance()
+		negative := tok.kind == tokMinus && p.peek().kind == tokNumber
+		if negative {
+			tok = p.advance()
+		}
+		if tok.kind != tokNumber && tok.kind != tokString && tok.kind != tokTrue && tok.kind != tokFalse {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected literal in list, found %s", describe(tok)))
+		}
+		item, err := p.literal(tok)
+		if err != nil {
+			return nil, err
+		}
+		value := item.(*literalNode).value
+		if negative {
+			value = negate(value)
+		}
+		list.items = append(list.items, value)
+		tok = p.advance()
+		if tok.kind == tokRBracket {
+			return list, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected , or ] to close [ at column %d, found %s", open.pos+1, describe(tok)))
+		}
+	}
+}
+
+func (p *exprParser) literal(tok token) (exprNode, error) {
+	switch tok.kind {
+	case tokNumber:
+		value, err := parseNumber(tok.text)
+		if err != nil {
+			return nil, p.errorAt(tok, fmt.Sprintf("invalid number %q", tok.text))
+		}
+		return &literalNode{value: value, pos: tok.pos}, nil
+	case tokString:
+		return &literalNode{value: tok.text, pos: tok.pos}, nil
+	default:
+		return &literalNode{value: tok.kind == tokTrue, pos: tok.pos}, nil
+	}
+}
+
+// parseNumber reads an integer literal as int64 and one with a fraction as float64.
+func parseNumber(text string) (any, error) {
+	if strings.Contains(text, ".") {
+		return strconv.ParseFloat(text, 64)
+	}
+	return strconv.ParseInt(text, 10, 64)
+}
+
+func negate(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return -v
+	case float64:
+		return -v
+	}
+	return value
+}
+
+func isConstant(node exprNode) bool {
+	switch node.(type) {
+	case *literalNode, *listNode:
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *exprParser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *exprParser) errorAt(tok token, msg string) error {
+	return &ConditionError{Cond: p.src, Pos: tok.pos, Msg: msg}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return tok.kind.String()
+	case tokIdent, tokNumber:
+		return fmt.Sprintf("%s %s", tok.kind, tok.text)
+	case tokString:
+		return fmt.Sprintf("string %q", tok.text)
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCondition(t *testing.T) {
+	t.Run("&& binds tighter than ||", func(t *testing.T) {
+		// Act
+		node, err := parseCondition("a==1 || b==2 && c==3")
+
+		// Assert
+		require.NoError(t, err)
+		or, ok := node.(*logicalNode)
+		require.True(t, ok)
+		assert.Equal(t, tokOr, or.op)
+		assert.IsType(t, &comparisonNode{}, or.left)
+		and, ok := or.right.(*logicalNode)
+		require.True(t, ok)
+		assert.Equal(t, tokAnd, and.op)
+	})
+	t.Run("parentheses group before precedence", func(t *testing.T) {
+		// Act
+		node, err := parseCondition("(a==1 || b==2) && c==3")
+
+		// Assert
+		require.NoError(t, err)
+		and, ok := node.(*logicalNode)
+		require.True(t, ok)
+		assert.Equal(t, tokAnd, and.op)
+		assert.IsType(t, &logicalNode{}, and.left)
+	})
+	t.Run("literals are typed", func(t *testing.T) {
+		// Act
+		node, err := parseCondition(`score > -1.5 && name == "x" && ok == true`)
+
+		// Assert
+		require.NoError(t, err)
+		and := node.(*logicalNode)
+		assert.Equal(t, "x", and.left.(*logicalNode).right.(*comparisonNode).right.(*literalNode).value)
+		assert.Equal(t, -1.5, and.left.(*logicalNode).left.(*comparisonNode).right.(*literalNode).value)
+		assert.Equal(t, true, and.right.(*comparisonNode).right.(*literalNode).value)
+	})
+	t.Run("variables may appear on either side", func(t *testing.T) {
+		// Act
+		node, err := parseCondition("requested_amount <= credit_limit && 18 <= age")
+
+		// Assert
+		require.NoError(t, err)
+		and := node.(*logicalNode)
+		left := and.left.(*comparisonNode)
+		assert.Equal(t, "requested_amount", left.left.(*identNode).name)
+		assert.Equal(t, "credit_limit", left.right.(*identNode).name)
+		assert.IsType(t, &literalNode{}, and.right.(*comparisonNode).left)
+	})
+	t.Run("calls, lists and in", func(t *testing.T) {
+		// Act
+		node, err := parseCondition(`state in ["SP", 1, true] && contains(name, "a")`)
+
+		// Assert
+		require.NoError(t, err)
+		and := node.(*logicalNode)
+		in := and.left.(*comparisonNode)
+		assert.Equal(t, tokIn, in.op)
+		assert.Equal(t, []any{"SP", int64(1), true}, in.right.(*listNode).items)
+		call := and.right.(*callNode)
+		assert.Equal(t, "contains", call.name)
+		assert.Len(t, call.args, 2)
+	})
+	t.Run("arithmetic binds tighter than comparisons", func(t *testing.T) {
+		// Act
+		node, err := parseCondition("a + b * -c >= -2")
+
+		// Assert
+		require.NoError(t, err)
+		cmp := node.(*comparisonNode)
+		assert.Equal(t, int64(-2), cmp.right.(*literalNode).value)
+		sum := cmp.left.(*arithmeticNode)
+		assert.Equal(t, tokPlus, sum.op)
+		product := sum.right.(*arithmeticNode)
+		assert.Equal(t, tokStar, product.op)
+		assert.Equal(t, tokMinus, product.right.(*arithmeticNode).op)
+	})
+	t.Run("variables are paths", func(t *testing.T) {
+		// Act
+		node, err := parseCondition("items[0].price > 10")
+
+		// Assert
+		require.NoError(t, err)
+		ident := node.(*comparisonNode).left.(*identNode)
+		assert.Equal(t, "items[0].price", ident.name)
+		assert.Equal(t, []pathSegment{{key: "items"}, {index: 0, isIndex: true}, {key: "price"}}, ident.path)
+	})
+	t.Run("errors report the offending column", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			pos  int
+			msg  string
+		}{
+			{cond: "age >= ", pos: 7, msg: "expected variable or literal, found end of condition"},
+			{cond: "(age > 1", pos: 8, msg: "expected ) to close ( at column 1, found end of condition"},
+			{cond: "age > 1)", pos: 7, msg: `unexpected ")"`},
+			{cond: "age ! 1", pos: 4, msg: "unexpected character '!'"},
+			{cond: `name == "x`, pos: 8, msg: "unterminated string"},
+			{cond: "1 == 2", pos: 2, msg: "comparison between two literals; one side must be a variable"},
+			{cond: "== age", pos: 0, msg: `expected variable or literal, found "=="`},
+			{cond: "age 18", pos: 4, msg: "expected comparison operator, found number 18"},
+			{cond: `state in ["SP" "RJ"]`, pos: 15, msg: `expected , or ] to close [ at column 10, found string "RJ"`},
+			{cond: "state in [code]", pos: 10, msg: "expected literal in list, found variable code"},
+			{cond: "a. == 1", pos: 3, msg: `expected field name after ., found "=="`},
+			{cond: "items[x] == 1", pos: 6, msg: "expected list index, found variable x"},
+			{cond: "items[-1] == 1", pos: 6, msg: `expected list index, found "-"`},
+			{cond: "items[0 == 1", pos: 8, msg: `expected ] to close [ at column 6, found "=="`},
+			{cond: "len(name > 1", pos: 9, msg: `expected , or ) to close ( at column 4, found ">"`},
+		}
+		for _, tc := range cases {
+			// Act
+			_, err := parseCondition(tc.cond)
+
+			// Assert
+			var condErr *ConditionError
+			require.ErrorAs(t, err, &condErr, tc.cond)
+			assert.Equal(t, tc.pos, condErr.Pos, tc.cond)
+			assert.Equal(t, tc.msg, condErr.Msg, tc.cond)
+			assert.ErrorIs(t, err, ErrInvalidCondition)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ValueType is the type of a value seen by conditions. Numbers of every Go numeric type are
+// TypeNumber and slices and arrays (JSON arrays) are TypeList.
+type ValueType string
+
+const (
+	TypeNumber ValueType = "number"
+	TypeString ValueType = "string"
+	TypeBool   ValueType = "bool"
+	TypeNull   ValueType = "null"
+	TypeList   ValueType = "list"
+	TypeObject ValueType = "object"
+)
+
+// Function is a condition function implemented in Go. Its arguments already match the
+// declared Signature; it must not have side effects, since conditions may be evaluated
+// any number of times and concurrently.
+type Function func(args []any) (any, error)
+
+// Signature declares the parameter types and result type of a Function.
+type Signature struct {
+	Params []ValueType
+	Result ValueType
+}
+
+// FunctionRegistry holds the functions a condition may call. DotParser rejects policies that
+// call a function it does not know and GraphExecutor resolves calls against it, so both
+// should be given the same registry.
+type FunctionRegistry struct {
+	mu        sync.RWMutex
+	functions map[string]*function
+}
+
+// builtinRegistry backs parsers and executors created without a registry.
+var builtinRegistry = NewFunctionRegistry()
+
+// NewFunctionRegistry returns a registry holding the built-in functions.
+func NewFunctionRegistry() *FunctionRegistry {
+	functions := make(map[string]*function, len(builtinFunctions))
+	for name, fn := range builtinFunctions {
+		functions[name] = fn
+	}
+	return &FunctionRegistry{functions: functions}
+}
+
+// Register adds fn under name. The name must be a valid identifier that is not yet
+// registered, so built-ins cannot be replaced.
+func (r *FunctionRegistry) Register(name string, sig Signature, fn Function) error {
+	if !isIdentifier(name) || identOrKeyword(name, 0).kind != tokIdent {
+		return fmt.Errorf("%w: %q is not a valid function name", ErrInvalidFunction, name)
+	}
+	if fn == nil {
+		return fmt.Errorf("%w: %s has no implementation", ErrInvalidFunction, name)
+	}
+	if !knownType(sig.Result) {
+		return fmt.Errorf("%w: %s has unknown result type %q", ErrInvalidFunction, name, sig.Result)
+	}
+	params := make([][]ValueType, len(sig.Params))
+	for i, t := range sig.Params {
+		if !knownType(t) {
+			return fmt.Errorf("%w: %s has unknown type %q for argument %d", ErrInvalidFunction, name, t, i+1)
+		}
+		params[i] = []ValueType{t}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.functions[name]; exists {
+		return fmt.Errorf("%w: %s is already registered", ErrInvalidFunction, name)
+	}
+	r.functions[name] = &function{name: name, params: params, result: sig.Result, call: funcImpl(fn)}
+	return nil
+}
+
+func (r *FunctionRegistry) lookup(name string) (*function, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.functions[name]
+	return fn, ok
+}
+
+// orBuiltins returns r, or the built-in registry when r is nil.
+func (r *FunctionRegistry) orBuiltins() *FunctionRegistry {
+	if r == nil {
+		return builtinRegistry
+	}
+	return r
+}
+
+func knownType(t ValueType) bool {
+	switch t {
+	case TypeNumber, TypeString, TypeBool, TypeNull, TypeList, TypeObject:
+		return true
+	}
+	return false
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || !isIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentPart(name[i]) {
+			return false
+		}
+	}
+	return true
+}
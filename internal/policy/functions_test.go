@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validCPF(args []any) (any, error) {
+	return len(args[0].(string)) == 11, nil
+}
+
+func TestFunctionRegistry(t *testing.T) {
+	cpfSignature := Signature{Params: []ValueType{TypeString}, Result: TypeBool}
+	cpfDOT := `digraph { start [result=""]; ok [result="valid=true"]; start -> ok [cond="validCPF(cpf)"]; }`
+
+	t.Run("registered function is callable from a policy", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		require.NoError(t, functions.Register("validCPF", cpfSignature, validCPF))
+		graph, err := NewDotParser(functions).Parse(context.Background(), cpfDOT)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := NewGraphExecutor(functions).Process(context.Background(), graph, map[string]any{"cpf": "12345678901"}, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Node)
+		assert.Equal(t, true, resp.Output["valid"])
+	})
+	t.Run("parser rejects calls to unknown functions", func(t *testing.T) {
+		// Act
+		_, err := NewDotParser(nil).Parse(context.Background(), cpfDOT)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "unknown function validCPF", condErr.Msg)
+		assert.ErrorIs(t, err, ErrInvalidCondition)
+	})
+	t.Run("parser rejects calls with the wrong arity or argument types", func(t *testing.T) {
+		cases := []struct {
+			cond string
+			msg  string
+		}{
+			{cond: "len(a, b) > 1", msg: "len takes 1 arguments, got 2"},
+			{cond: `x == 1 || startsWith(name, 3)`, msg: "argument 2 of startsWith must be string, got number"},
+			{cond: "len(name)", msg: "len returns number, not a condition"},
+		}
+		for _, tc := range cases {
+			// Arrange
+			dot := `digraph { start [result=""]; a [result=""]; start -> a [cond="` + strings.ReplaceAll(tc.cond, `"`, `\"`) + `"]; }`
+
+			// Act
+			_, err := NewDotParser(nil).Parse(context.Background(), dot)
+
+			// Assert
+			var condErr *ConditionError
+			require.ErrorAs(t, err, &condErr, tc.cond)
+			assert.Equal(t, tc.msg, condErr.Msg, tc.cond)
+		}
+	})
+	t.Run("parser leaves conditions without calls to evaluation", func(t *testing.T) {
+		// Arrange
+		dot := `digraph { start [result=""]; a [result=""]; start -> a [cond="x + 1"]; }`
+
+		// Act
+		_, err := NewDotParser(nil).Parse(context.Background(), dot)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+	t.Run("built-ins are available in a new registry", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		dot := `digraph { start [result=""]; ok [result=""]; start -> ok [cond="len(name) > 2"]; }`
+
+		// Act
+		_, err := NewDotParser(functions).Parse(context.Background(), dot)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+	t.Run("registered function computes node results", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		band := func(args []any) (any, error) {
+			if args[0].(float64) >= 700 {
+				return "low", nil
+			}
+			return "high", nil
+		}
+		require.NoError(t, functions.Register("band", Signature{Params: []ValueType{TypeNumber}, Result: TypeString}, band))
+		dot := `digraph { start [result="score=score+50"]; bonus [result="score=score+200, risk_band=band(score)"]; start -> bonus [cond="score > 500"]; }`
+		graph, err := NewDotParser(functions).Parse(context.Background(), dot)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := NewGraphExecutor(functions).Process(context.Background(), graph, map[string]any{"score": 600.0}, ExecOptions{Explain: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 850.0, resp.Output["score"])
+		assert.Equal(t, "low", resp.Output["risk_band"])
+		assert.Equal(t, map[string]any{"score": 850.0, "risk_band": "low"}, resp.Trace[1].Assigned)
+	})
+	t.Run("parser rejects results calling unknown functions", func(t *testing.T) {
+		// Arrange
+		dot := `digraph { start [result="risk_band=band(score)"]; }`
+
+		// Act
+		_, err := NewDotParser(nil).Parse(context.Background(), dot)
+
+		// Assert
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.Equal(t, "risk_band: unknown function band", resultErr.Msg)
+	})
+	t.Run("argument types are checked before the call", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		require.NoError(t, functions.Register("validCPF", cpfSignature, validCPF))
+
+		// Act
+		got, err := compileCondition("validCPF(cpf)", functions).eval(map[string]any{"cpf": 123})
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "argument 1 of validCPF must be string, got number", condErr.Msg)
+		assert.False(t, got)
+	})
+	t.Run("result must match the declared type", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		require.NoError(t, functions.Register("score", Signature{Result: TypeNumber}, func([]any) (any, error) { return "high", nil }))
+
+		// Act
+		_, err := compileCondition("score() > 1", functions).eval(nil)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "score returned string, declared number", condErr.Msg)
+	})
+	t.Run("function errors are reported as condition errors", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		require.NoError(t, functions.Register("fail", Signature{Result: TypeBool}, func([]any) (any, error) { return nil, errors.New("backend down") }))
+
+		// Act
+		_, err := compileCondition("fail()", functions).eval(nil)
+
+		// Assert
+		var condErr *ConditionError
+		require.ErrorAs(t, err, &condErr)
+		assert.Equal(t, "fail: backend down", condErr.Msg)
+	})
+	t.Run("graph compiled for another registry is recompiled", func(t *testing.T) {
+		// Arrange
+		functions := NewFunctionRegistry()
+		require.NoError(t, functions.Register("validCPF", cpfSignature, validCPF))
+		graph, err := NewDotParser(functions).Parse(context.Background(), cpfDOT)
+		require.NoError(t, err)
+		_, err = NewGraphExecutor(nil).Process(context.Background(), graph, map[string]any{"cpf": "1"}, ExecOptions{})
+		require.ErrorIs(t, err, ErrInvalidCondition)
+
+		// Act
+		resp, err := NewGraphExecutor(functions).Process(context.Background(), graph, map[string]any{"cpf": "12345678901"}, ExecOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Node)
+	})
+	t.Run("invalid registrations are rejected", func(t *testing.T) {
+		cases := []struct {
+			name string
+			fn   string
+			sig  Signature
+			impl Function
+		}{
+			{name: "built-in cannot be replaced", fn: "len", sig: cpfSignature, impl: validCPF},
+			{name: "name must be an identifier", fn: "valid-cpf", sig: cpfSignature, impl: validCPF},
+			{name: "name must not be a keyword", fn: "in", sig: cpfSignature, impl: validCPF},
+			{name: "implementation is required", fn: "validCPF", sig: cpfSignature},
+			{name: "types must be known", fn: "validCPF", sig: Signature{Params: []ValueType{"date"}, Result: TypeBool}, impl: validCPF},
+		}
+		for _, tc := range cases {
+			// Arrange
+			functions := NewFunctionRegistry()
+
+			// Act
+			err := functions.Register(tc.fn, tc.sig, tc.impl)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidFunction, tc.name)
+		}
+	})
+}
@@ -4,9 +4,12 @@ import "context"
 
 type (
 	Executor interface {
-		Process(ctx context.Context, graph *Graph, input map[string]any) (InferResponse, error)
+		Process(ctx context.Context, graph *Graph, input map[string]any, opts ExecOptions) (InferResponse, error)
 	}
 	Parser interface {
 		Parse(ctx context.Context, dot string) (*Graph, error)
 	}
+	Validator interface {
+		Validate(ctx context.Context, dot string, opts ValidateOptions) (ValidationReport, error)
+	}
 )
@@ -2,84 +2,319 @@ package policy
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/awalterschulze/gographviz"
 	"github.com/awalterschulze/gographviz/ast"
 )
 
-type DotParser struct{}
+type DotParser struct {
+	functions *FunctionRegistry
+}
 
-func NewDotParser() *DotParser {
-	return &DotParser{}
+// NewDotParser returns a parser that rejects conditions calling functions missing from
+// functions; nil means the built-ins only.
+func NewDotParser(functions *FunctionRegistry) *DotParser {
+	return &DotParser{functions: functions.orBuiltins()}
 }
 
-func (DotParser) Parse(ctx context.Context, dot string) (*Graph, error) {
+func (p DotParser) Parse(ctx context.Context, dot string) (*Graph, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
 	astGraph, err := gographviz.ParseString(dot)
 	if err != nil {
 		return nil, ErrInvalidPolicyDot
 	}
 
-	nodes, edges := buildGraphFromAST(astGraph)
+	nodes, edges, err := buildGraphFromAST(ctx, astGraph)
+	if err != nil {
+		return nil, err
+	}
 	if err = validateHasStart(nodes); err != nil {
 		return nil, err
 	}
-	return &Graph{Nodes: nodes, Edges: edges, Start: StartNodeID}, nil
+	if from, ok := multipleDefaults(edges); ok {
+		return nil, fmt.Errorf("%w: node %s has more than one default edge", ErrInvalidPolicyDot, from)
+	}
+	if err = validateExpressions(nodes, edges, p.functions.orBuiltins()); err != nil {
+		return nil, err
+	}
+	maxSteps, err := maxStepsFromAST(astGraph)
+	if err != nil {
+		return nil, err
+	}
+	return &Graph{Nodes: nodes, Edges: edges, Start: StartNodeID, MaxSteps: maxSteps}, nil
 }
 
-func buildGraphFromAST(astGraph *ast.Graph) (map[string]*Node, []*Edge) {
-	nodes := make(map[string]*Node)
-	var edges []*Edge
-	for _, stmt := range astGraph.StmtList {
-		if nodeStmt, ok := stmt.(*ast.NodeStmt); ok {
-			node := nodeFromStmt(nodeStmt)
-			nodes[node.ID] = node
+// validateExpressions rejects malformed results, computed results that do not parse or check
+// against functions, and conditions whose function calls do not check against functions:
+// unknown functions, wrong arity and arguments of the wrong type. Other condition errors are
+// left to evaluation, so an invalid edge only fails the paths that reach it.
+func validateExpressions(nodes map[string]*Node, edges []*Edge, functions *FunctionRegistry) error {
+	for _, id := range slices.Sorted(maps.Keys(nodes)) {
+		if err := compileResult(nodes[id].Result, functions).err; err != nil {
+			return err
+		}
+	}
+	for _, edge := range edges {
+		if edge.Cond == "" {
+			continue
+		}
+		node, err := parseCondition(edge.Cond)
+		if err != nil || !hasCall(node) {
+			continue
+		}
+		if err := checkCondition(edge.Cond, node, functions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	resultAttr   = "result"
+	condAttr     = "cond"
+	priorityAttr = "priority"
+	defaultAttr  = "default"
+)
+
+func buildGraphFromAST(ctx context.Context, astGraph *ast.Graph) (map[string]*Node, []*Edge, error) {
+	b, err := newGraphBuilder(ctx, astGraph)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.nodes, b.edges, nil
+}
+
+// graphBuilder collects the nodes and edges of a DOT graph, descending into subgraphs and
+// clusters as if their statements were written at the top level. duplicates lists, in
+// declaration order, the nodes declared by more than one node statement; fromEdges holds the
+// nodes declared by an edge endpoint and not yet by a node statement.
+type graphBuilder struct {
+	ctx        context.Context
+	nodes      map[string]*Node
+	edges      []*Edge
+	duplicates []string
+	fromEdges  map[string]bool
+}
+
+func newGraphBuilder(ctx context.Context, astGraph *ast.Graph) (*graphBuilder, error) {
+	b := &graphBuilder{ctx: ctx, nodes: make(map[string]*Node), fromEdges: make(map[string]bool)}
+	if _, err := b.walk(astGraph.StmtList, attrDefaults{node: map[string]string{}, edge: map[string]string{}}); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// attrDefaults holds the attributes set by `node [...]` and `edge [...]` statements. As in
+// Graphviz, they apply to nodes and edges declared after them in the same graph or subgraph,
+// including nested subgraphs, and stop applying when the subgraph that set them ends.
+type attrDefaults struct {
+	node map[string]string
+	edge map[string]string
+}
+
+func (d attrDefaults) clone() attrDefaults {
+	return attrDefaults{node: maps.Clone(d.node), edge: maps.Clone(d.edge)}
+}
+
+// walk processes stmts in order and returns the IDs of the nodes they mention, which are the
+// endpoints of an edge to or from the enclosing subgraph, as in `start -> { a b }`.
+func (b *graphBuilder) walk(stmts ast.StmtList, defaults attrDefaults) ([]string, error) {
+	var ids []string
+	for _, stmt := range stmts {
+		if err := checkContext(b.ctx); err != nil {
+			return nil, err
+		}
+		switch s := stmt.(type) {
+		case *ast.NodeStmt:
+			ids = append(ids, b.addNode(s, defaults))
+		case *ast.EdgeStmt:
+			endpoints, err := b.addEdges(s, defaults)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, endpoints...)
+		case *ast.SubGraph:
+			nested, err := b.walk(s.StmtList, defaults.clone())
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, nested...)
+		case ast.NodeAttrs:
+			maps.Copy(defaults.node, ast.AttrList(s).GetMap())
+		case ast.EdgeAttrs:
+			maps.Copy(defaults.edge, ast.AttrList(s).GetMap())
+		}
+	}
+	return ids, nil
+}
+
+// addNode declares the node of stmt with the current node defaults. Declaring a node again
+// only overrides the attributes the later statement sets.
+func (b *graphBuilder) addNode(stmt *ast.NodeStmt, defaults attrDefaults) string {
+	id := string(stmt.NodeID.GetID())
+	attrs := stmt.Attrs.GetMap()
+	node, ok := b.nodes[id]
+	if !ok {
+		node = &Node{ID: id, Result: dotString(defaults.node[resultAttr])}
+		b.nodes[id] = node
+	} else if b.fromEdges[id] {
+		delete(b.fromEdges, id)
+	} else if !slices.Contains(b.duplicates, id) {
+		b.duplicates = append(b.duplicates, id)
+	}
+	if result, ok := attrs[resultAttr]; ok {
+		node.Result = dotString(result)
+	}
+	return id
+}
+
+// addEdges expands a chain such as `a -> b -> c [cond="x==1"]` into one edge per hop, each
+// carrying the statement's attributes over the current edge defaults. A subgraph endpoint
+// stands for every node it mentions, so `a -> { b c }` adds a -> b and a -> c.
+func (b *graphBuilder) addEdges(stmt *ast.EdgeStmt, defaults attrDefaults) ([]string, error) {
+	attrs := maps.Clone(defaults.edge)
+	maps.Copy(attrs, stmt.Attrs.GetMap())
+	template, err := edgeTemplate(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := b.endpoints(stmt.Source, defaults)
+	if err != nil {
+		return nil, err
+	}
+	ids := slices.Clone(from)
+	for _, hop := range stmt.EdgeRHS {
+		to, err := b.endpoints(hop.Destination, defaults)
+		if err != nil {
+			return nil, err
 		}
-		if edgeStmt, ok := stmt.(*ast.EdgeStmt); ok {
-			if edge, ok := edgeFromStmt(edgeStmt); ok {
-				edges = append(edges, edge)
+		for _, src := range from {
+			for _, dst := range to {
+				edge := template
+				edge.From, edge.To = src, dst
+				b.edges = append(b.edges, &edge)
 			}
 		}
+		ids = append(ids, to...)
+		from = to
 	}
-	return nodes, edges
+	return ids, nil
 }
 
-func nodeFromStmt(stmt *ast.NodeStmt) *Node {
-	id := string(stmt.NodeID.ID)
-	result := extractResultFromNodeAttrs(stmt.Attrs)
-	return &Node{ID: id, Result: result}
+// edgeTemplate reads the cond, priority and default attributes shared by the hops of an edge
+// statement; cond="else" is shorthand for default=true.
+func edgeTemplate(attrs map[string]string) (Edge, error) {
+	edge := Edge{Cond: dotString(attrs[condAttr])}
+	if raw, ok := attrs[priorityAttr]; ok {
+		priority, err := strconv.Atoi(dotString(raw))
+		if err != nil {
+			return Edge{}, fmt.Errorf("%w: %s must be an integer, got %q", ErrInvalidPolicyDot, priorityAttr, dotString(raw))
+		}
+		edge.Priority = priority
+	}
+	if raw, ok := attrs[defaultAttr]; ok {
+		isDefault, err := strconv.ParseBool(dotString(raw))
+		if err != nil {
+			return Edge{}, fmt.Errorf("%w: %s must be true or false, got %q", ErrInvalidPolicyDot, defaultAttr, dotString(raw))
+		}
+		edge.Default = isDefault
+	}
+	if edge.Cond == ElseCond {
+		edge.Cond, edge.Default = "", true
+	}
+	if edge.Default && edge.Cond != "" {
+		return Edge{}, fmt.Errorf("%w: a default edge cannot have a condition, got %q", ErrInvalidPolicyDot, edge.Cond)
+	}
+	return edge, nil
 }
 
-func extractResultFromNodeAttrs(attrs ast.AttrList) string {
-	for _, a := range attrs {
-		s := a.String()
-		if strings.HasPrefix(s, "result=") {
-			return strings.Trim(strings.TrimPrefix(s, "result="), "\"")
+func (b *graphBuilder) endpoints(loc ast.Location, defaults attrDefaults) ([]string, error) {
+	sub, ok := loc.(*ast.SubGraph)
+	if !ok {
+		id := string(loc.GetID())
+		b.addEndpoint(id, defaults)
+		return []string{id}, nil
+	}
+	ids, err := b.walk(sub.StmtList, defaults.clone())
+	if err != nil {
+		return nil, err
+	}
+	var unique []string
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
 		}
 	}
-	return ""
+	return unique, nil
 }
 
-func edgeFromStmt(stmt *ast.EdgeStmt) (*Edge, bool) {
-	if len(stmt.EdgeRHS) == 0 {
-		return nil, false
+// addEndpoint declares a node first seen as an edge endpoint when the current node defaults set
+// a result, as Graphviz applies node defaults to nodes created by edges. Without such a default
+// the node stays undeclared, and a walk reaching it stops with TerminationUndeclaredNode.
+func (b *graphBuilder) addEndpoint(id string, defaults attrDefaults) {
+	result, ok := defaults.node[resultAttr]
+	if _, declared := b.nodes[id]; declared || !ok {
+		return
 	}
-	from := string(stmt.Source.GetID())
-	to := string(stmt.EdgeRHS[0].Destination.GetID())
-	cond := extractCondFromEdgeAttrs(stmt.Attrs)
-	return &Edge{From: from, To: to, Cond: cond}, true
+	b.nodes[id] = &Node{ID: id, Result: dotString(result)}
+	b.fromEdges[id] = true
 }
 
-func extractCondFromEdgeAttrs(attrs ast.AttrList) string {
-	for _, attrList := range attrs {
-		for _, a := range attrList {
-			s := a.String()
-			if strings.HasPrefix(s, "cond=") {
-				return strings.Trim(strings.TrimPrefix(s, "cond="), "\"")
+// maxStepsFromAST reads the max_steps graph attribute, set either as `max_steps=N` or `graph [max_steps=N]`.
+func maxStepsFromAST(astGraph *ast.Graph) (int, error) {
+	raw := ""
+	for _, stmt := range astGraph.StmtList {
+		switch s := stmt.(type) {
+		case *ast.Attr:
+			if string(s.Field) == MaxStepsAttr {
+				raw = string(s.Value)
 			}
+		case ast.GraphAttrs:
+			if v, ok := ast.AttrList(s).GetMap()[MaxStepsAttr]; ok {
+				raw = v
+			}
+		}
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	maxSteps, err := strconv.Atoi(strings.Trim(raw, "\""))
+	if err != nil || maxSteps <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidPolicyDot, MaxStepsAttr)
+	}
+	return maxSteps, nil
+}
+
+// dotString returns the text of a DOT attribute value: a quoted string loses its quotes and
+// its \" escapes and line continuations, as in cond="name == \"SP\"". Other IDs are returned as is.
+func dotString(raw string) string {
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return raw
+	}
+	return strings.NewReplacer(`\"`, `"`, "\\\n", "", "\\\r\n", "").Replace(raw[1 : len(raw)-1])
+}
+
+// multipleDefaults returns the first node, in edge order, with more than one default edge.
+func multipleDefaults(edges []*Edge) (string, bool) {
+	seen := make(map[string]bool)
+	for _, edge := range edges {
+		if !edge.Default {
+			continue
+		}
+		if seen[edge.From] {
+			return edge.From, true
 		}
+		seen[edge.From] = true
 	}
-	return ""
+	return "", false
 }
 
 func validateHasStart(nodes map[string]*Node) error {
@@ -3,8 +3,10 @@ package policy
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseDOT(t *testing.T) {
@@ -14,7 +16,7 @@ func TestParseDOT(t *testing.T) {
 
 	t.Run("valid DOT returns graph with start", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
+		parser := NewDotParser(nil)
 
 		// Act
 		graph, err := parser.Parse(context.Background(), validDOT)
@@ -31,7 +33,7 @@ func TestParseDOT(t *testing.T) {
 	})
 	t.Run("DOT without start node returns ErrNoStartNode", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
+		parser := NewDotParser(nil)
 
 		// Act
 		_, err := parser.Parse(context.Background(), dotWithoutStart)
@@ -42,7 +44,7 @@ func TestParseDOT(t *testing.T) {
 	})
 	t.Run("invalid DOT syntax returns error", func(t *testing.T) {
 		// Arrange
-		parser := NewDotParser()
+		parser := NewDotParser(nil)
 
 		// Act
 		_, err := parser.Parse(context.Background(), invalidDOT)
@@ -51,4 +53,258 @@ func TestParseDOT(t *testing.T) {
 		assert.Error(t, err)
 		assert.NotErrorIs(t, err, ErrNoStartNode)
 	})
+	t.Run("max_steps graph attribute sets step budget", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { graph [max_steps=25]; start [result=""]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 25, graph.MaxSteps)
+	})
+	t.Run("max_steps top-level attribute sets step budget", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { max_steps="7"; start [result=""]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 7, graph.MaxSteps)
+	})
+	t.Run("non positive max_steps returns ErrInvalidPolicyDot", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { max_steps=0; start [result=""]; }`
+
+		// Act
+		_, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidPolicyDot)
+	})
+	t.Run("escaped quotes in attributes are unescaped", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result="reason=\"age, score\""]; ok [result=""]; start -> ok [cond="state == \"SP\""]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, `reason="age, score"`, graph.Nodes["start"].Result)
+		assert.Equal(t, `state == "SP"`, graph.Edges[0].Cond)
+	})
+	t.Run("malformed result returns ResultError", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result=""]; ok [result="approved=true, oops"]; start -> ok [cond="true"]; }`
+
+		// Act
+		_, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		var resultErr *ResultError
+		require.ErrorAs(t, err, &resultErr)
+		assert.Equal(t, `expected = after "oops"`, resultErr.Msg)
+		assert.ErrorIs(t, err, ErrInvalidResult)
+	})
+	t.Run("edge chain expands into one edge per hop with shared attributes", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result=""]; check [result=""]; approve [result="ok=true"]; start -> check -> approve [cond="x==1"]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []*Edge{
+			{From: "start", To: "check", Cond: "x==1"},
+			{From: "check", To: "approve", Cond: "x==1"},
+		}, graph.Edges)
+	})
+	t.Run("edge chains keep statement order", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result=""]; a -> b -> c; start -> a [cond="y>0"]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []*Edge{
+			{From: "a", To: "b"},
+			{From: "b", To: "c"},
+			{From: "start", To: "a", Cond: "y>0"},
+		}, graph.Edges)
+	})
+	t.Run("nodes and edges inside subgraphs and clusters are parsed", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph {
+			start [result=""];
+			subgraph cluster_checks {
+				label="checks";
+				age [result="adult=true"];
+				subgraph inner { score [result="good=true"]; age -> score [cond="score>700"]; }
+			}
+			start -> age [cond="age>=18"];
+		}`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "adult=true", graph.Nodes["age"].Result)
+		assert.Equal(t, "good=true", graph.Nodes["score"].Result)
+		assert.Equal(t, []*Edge{
+			{From: "age", To: "score", Cond: "score>700"},
+			{From: "start", To: "age", Cond: "age>=18"},
+		}, graph.Edges)
+	})
+	t.Run("default attributes apply to later statements in their scope", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph {
+			start [result=""];
+			node [result="reviewed=true"];
+			edge [cond="ok==true"];
+			a; b [result="b=1"];
+			subgraph cluster_x {
+				node [result="inner=true"];
+				edge [cond="x==1"];
+				c;
+				a -> c;
+			}
+			d;
+			start -> a;
+			a -> b [cond="y==2"];
+		}`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "", graph.Nodes["start"].Result)
+		assert.Equal(t, "reviewed=true", graph.Nodes["a"].Result)
+		assert.Equal(t, "b=1", graph.Nodes["b"].Result)
+		assert.Equal(t, "inner=true", graph.Nodes["c"].Result)
+		assert.Equal(t, "reviewed=true", graph.Nodes["d"].Result)
+		assert.Equal(t, []*Edge{
+			{From: "a", To: "c", Cond: "x==1"},
+			{From: "start", To: "a", Cond: "ok==true"},
+			{From: "a", To: "b", Cond: "y==2"},
+		}, graph.Edges)
+	})
+	t.Run("nodes created by edges inherit the node defaults in scope", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph {
+			start [result=""];
+			start -> early;
+			node [result="x=1"];
+			start -> a -> b;
+			subgraph s { node [result="y=2"]; a -> c; }
+			b [result="z=3"];
+		}`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotContains(t, graph.Nodes, "early")
+		assert.Equal(t, "", graph.Nodes["start"].Result)
+		assert.Equal(t, "x=1", graph.Nodes["a"].Result)
+		assert.Equal(t, "z=3", graph.Nodes["b"].Result)
+		assert.Equal(t, "y=2", graph.Nodes["c"].Result)
+	})
+	t.Run("redeclaring a node keeps attributes it does not set", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result="x=1"]; node [result="y=2"]; start [color=red]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "x=1", graph.Nodes["start"].Result)
+	})
+	t.Run("subgraph endpoints expand to every node they mention", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result=""]; start -> { a b; a -> b } [cond="z>0"]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, graph.Nodes, "a")
+		assert.Contains(t, graph.Nodes, "b")
+		assert.Equal(t, []*Edge{
+			{From: "a", To: "b"},
+			{From: "start", To: "a", Cond: "z>0"},
+			{From: "start", To: "b", Cond: "z>0"},
+		}, graph.Edges)
+	})
+	t.Run("priority and default edge attributes are parsed", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		dot := `digraph { start [result=""]; start -> a [cond="x==1", priority=5]; start -> b [default=true]; a -> c [cond="y==1"]; a -> d [cond="else"]; }`
+
+		// Act
+		graph, err := parser.Parse(context.Background(), dot)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []*Edge{
+			{From: "start", To: "a", Cond: "x==1", Priority: 5},
+			{From: "start", To: "b", Default: true},
+			{From: "a", To: "c", Cond: "y==1"},
+			{From: "a", To: "d", Default: true},
+		}, graph.Edges)
+	})
+	t.Run("invalid edge priority and default attributes return ErrInvalidPolicyDot", func(t *testing.T) {
+		cases := map[string]string{
+			"more than one default":     `digraph { start [result=""]; start -> a [default=true]; start -> b [cond="else"]; }`,
+			"default with a condition":  `digraph { start [result=""]; start -> a [default=true, cond="x==1"]; }`,
+			"non integer priority":      `digraph { start [result=""]; start -> a [cond="x==1", priority=high]; }`,
+			"non boolean default value": `digraph { start [result=""]; start -> a [default=maybe]; }`,
+		}
+		for name, dot := range cases {
+			// Arrange
+			parser := NewDotParser(nil)
+
+			// Act
+			_, err := parser.Parse(context.Background(), dot)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidPolicyDot, name)
+		}
+	})
+	t.Run("expired deadline returns ErrTimeout", func(t *testing.T) {
+		// Arrange
+		parser := NewDotParser(nil)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		// Act
+		_, err := parser.Parse(ctx, validDOT)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTimeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
 }
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// pathSegment is one step of a variable reference: a map key, or a list index when isIndex is set.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// lookupPath resolves path against vars, e.g. customer.address.state or items[0].price. When
+// the value cannot be reached it returns a message naming the part of the path that failed.
+func lookupPath(vars map[string]any, path []pathSegment) (any, string) {
+	value, ok := vars[path[0].key]
+	name := path[0].key
+	if !ok {
+		return nil, fmt.Sprintf("variable %s is not set", pathName(path))
+	}
+	for _, seg := range path[1:] {
+		if seg.isIndex {
+			items, ok := toList(value)
+			if !ok {
+				return nil, fmt.Sprintf("variable %s is %s, not a list", name, typeOf(value))
+			}
+			if seg.index >= len(items) {
+				return nil, fmt.Sprintf("variable %s has %d items, index %d is out of range", name, len(items), seg.index)
+			}
+			value = items[seg.index]
+			name = fmt.Sprintf("%s[%d]", name, seg.index)
+			continue
+		}
+		fields, ok := toObject(value)
+		if !ok {
+			return nil, fmt.Sprintf("variable %s is %s, not an object", name, typeOf(value))
+		}
+		if value, ok = fields[seg.key]; !ok {
+			return nil, fmt.Sprintf("variable %s is not set", pathName(path))
+		}
+		name += "." + seg.key
+	}
+	return value, ""
+}
+
+// lookupKey returns the value at a dotted result key such as decision.status, if it is set.
+func lookupKey(vars map[string]any, key string) (any, bool) {
+	var path []pathSegment
+	for _, part := range strings.Split(key, ".") {
+		path = append(path, pathSegment{key: part})
+	}
+	value, msg := lookupPath(vars, path)
+	return value, msg == ""
+}
+
+func pathName(path []pathSegment) string {
+	var b strings.Builder
+	for i, seg := range path {
+		switch {
+		case seg.isIndex:
+			fmt.Fprintf(&b, "[%d]", seg.index)
+		case i > 0:
+			b.WriteString("." + seg.key)
+		default:
+			b.WriteString(seg.key)
+		}
+	}
+	return b.String()
+}
+
+// toObject returns the fields of a map with string keys, such as a JSON object decoded as map[string]any.
+func toObject(value any) (map[string]any, bool) {
+	if fields, ok := value.(map[string]any); ok {
+		return fields, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	fields := make(map[string]any, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		fields[iter.Key().String()] = iter.Value().Interface()
+	}
+	return fields, true
+}
+
+// setPath writes value at a dotted key such as decision.status, creating intermediate objects.
+// It reports false, writing nothing, when the key has an empty part or crosses a non-object value.
+func setPath(vars map[string]any, key string, value any) bool {
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+	}
+	target := vars
+	for _, part := range parts[:len(parts)-1] {
+		existing, ok := target[part]
+		if !ok {
+			child := make(map[string]any)
+			target[part] = child
+			target = child
+			continue
+		}
+		child, ok := existing.(map[string]any)
+		if !ok {
+			return false
+		}
+		target = child
+	}
+	target[parts[len(parts)-1]] = value
+	return true
+}
+
+// deepCopy copies JSON-shaped objects and lists so nested result writes never reach the caller's input.
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for k, item := range v {
+			copied[k] = deepCopy(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	}
+	return value
+}
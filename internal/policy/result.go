@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compiledResult is a node result split into assignments, applied left to right so a later
+// assignment sees the value of an earlier one. Like conditions, a result that fails to
+// compile keeps its error and reports it when its node is reached.
+type compiledResult struct {
+	src         string
+	assignments []assignment
+	err         error
+}
+
+// assignment writes either a literal or a computed value. An unquoted value is computed with
+// the condition engine when it is an expression: any value with a call, parentheses, brackets
+// or double quotes, such as risk_band=band(score) or risk=len(tier) > 3, and a value combining
+// variables with operators, such as bonus=score*2, once every variable it reads is set, or
+// whenever the key reads itself, as in score=score+50. Anything else keeps its literal
+// meaning: a quoted string, a bool, a number, or plain text such as high-risk, example.com or
+// 2024-01-01.
+type assignment struct {
+	key     string
+	keyPos  int
+	src     string
+	srcPos  int
+	quoted  bool
+	literal any
+	expr    exprNode
+	// reads are the variables expr reads; always is set when expr is computed even if some of
+	// them are not set.
+	reads  []*identNode
+	always bool
+}
+
+func compileResult(result string, functions *FunctionRegistry) compiledResult {
+	pairs, err := parseResult(result)
+	if err != nil {
+		return compiledResult{err: err}
+	}
+	compiled := compiledResult{src: result}
+	for _, pair := range pairs {
+		a := assignment{key: pair.key, keyPos: pair.keyPos, src: pair.value, srcPos: pair.valuePos, quoted: pair.quoted, literal: pair.value}
+		if !pair.quoted {
+			a.literal = parseResultValue(pair.value)
+			if err := a.compile(functions); err != nil {
+				return compiledResult{err: a.resultError(result, err)}
+			}
+		}
+		compiled.assignments = append(compiled.assignments, a)
+	}
+	return compiled
+}
+
+// compile sets the expression of a when its unquoted value is one. A value with a call,
+// parentheses, brackets or double quotes that does not compile is an error, since it is not
+// plain text either.
+func (a *assignment) compile(functions *FunctionRegistry) error {
+	marked := strings.ContainsAny(a.src, `()[]"`)
+	expr, err := parseValue(a.src)
+	if err != nil {
+		if marked {
+			return err
+		}
+		return nil
+	}
+	reads := identifiers(expr)
+	if _, single := expr.(*identNode); !marked && (len(reads) == 0 || single) {
+		return nil
+	}
+	if err := checkValue(a.src, expr, functions); err != nil {
+		return err
+	}
+	a.expr, a.reads = expr, reads
+	a.always = marked || slices.ContainsFunc(reads, func(id *identNode) bool { return id.name == a.key })
+	return nil
+}
+
+// computes reports whether the expression of a is evaluated against vars rather than written
+// as text.
+func (a assignment) computes(vars map[string]any) bool {
+	if a.always {
+		return true
+	}
+	return !slices.ContainsFunc(a.reads, func(id *identNode) bool {
+		_, ok := vars[id.path[0].key]
+		return !ok
+	})
+}
+
+func identifiers(node exprNode) []*identNode {
+	if id, ok := node.(*identNode); ok {
+		return []*identNode{id}
+	}
+	var ids []*identNode
+	for _, child := range children(node) {
+		ids = append(ids, identifiers(child)...)
+	}
+	return ids
+}
+
+// keepCounters re-reads as numbers the literals assigned to a key that some computed value in
+// results reads, so a counter started with attempt=0 counts up with attempt=attempt+1. Every
+// other literal keeps its meaning, in which 0 and 1 are false and true.
+func keepCounters(results []compiledResult) {
+	counters := make(map[string]bool)
+	for _, r := range results {
+		for _, a := range r.assignments {
+			for _, id := range a.reads {
+				counters[id.name] = true
+			}
+		}
+	}
+	for _, r := range results {
+		for i, a := range r.assignments {
+			if a.quoted || a.expr != nil || !counters[a.key] {
+				continue
+			}
+			if value, err := strconv.ParseFloat(a.src, 64); err == nil {
+				r.assignments[i].literal = value
+			}
+		}
+	}
+}
+
+// resultError locates a *ConditionError raised by the expression of a within result.
+func (a assignment) resultError(result string, err error) error {
+	var condErr *ConditionError
+	if !errors.As(err, &condErr) {
+		return err
+	}
+	return &ResultError{Result: result, Pos: a.srcPos + condErr.Pos, Msg: fmt.Sprintf("%s: %s", a.key, condErr.Msg), Err: condErr.Err}
+}
+
+// apply writes the assignments into vars and returns the pairs it wrote.
+func (r compiledResult) apply(vars map[string]any) (map[string]any, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.assignments) == 0 {
+		return nil, nil
+	}
+	assigned := make(map[string]any, len(r.assignments))
+	for _, a := range r.assignments {
+		value := a.literal
+		switch {
+		case a.expr != nil && a.computes(vars):
+			computed, err := evaluator{src: a.src, vars: vars}.value(a.expr)
+			if err != nil {
+				return nil, a.resultError(r.src, err)
+			}
+			value = computed
+		case a.expr != nil:
+			if current, ok := lookupKey(vars, a.key); ok && typeOf(current) != TypeString {
+				msg := fmt.Sprintf("%s is %s; %q reads a variable that is not set and would replace it with text", a.key, typeOf(current), a.src)
+				return nil, &ResultError{Result: r.src, Pos: a.srcPos, Msg: msg}
+			}
+		}
+		if !setPath(vars, a.key, value) {
+			return nil, &ResultError{Result: r.src, Pos: a.keyPos, Msg: fmt.Sprintf("%s crosses a value that is not an object", a.key)}
+		}
+		assigned[a.key] = value
+	}
+	return assigned, nil
+}
@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node results are parsed with this grammar; whitespace around keys and values is ignored:
+//
+//	result = [ pair { "," pair } ]
+//	pair   = key "=" value
+//	key    = IDENT { "." IDENT }
+//	value  = quoted | raw
+//	quoted = `"` { character | `\"` | `\\` } `"`
+//	raw    = text up to the next "," outside parentheses, brackets and double quotes
+//
+// A quoted value is always a string, so `reason="age, score"` keeps its comma.
+type resultPair struct {
+	key      string
+	keyPos   int
+	value    string
+	valuePos int
+	quoted   bool
+}
+
+// parseResult splits a node result into its pairs, or returns a *ResultError locating the
+// first malformed one.
+func parseResult(src string) ([]resultPair, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	var pairs []resultPair
+	for i := 0; ; i++ {
+		i = skipSpaces(src, i)
+		keyPos := i
+		for i < len(src) && src[i] != '=' && src[i] != ',' {
+			i++
+		}
+		key := strings.TrimSpace(src[keyPos:i])
+		if i >= len(src) || src[i] != '=' {
+			if key == "" {
+				return nil, &ResultError{Result: src, Pos: keyPos, Msg: "expected key=value pair"}
+			}
+			return nil, &ResultError{Result: src, Pos: keyPos, Msg: fmt.Sprintf("expected = after %q", key)}
+		}
+		if key == "" {
+			return nil, &ResultError{Result: src, Pos: keyPos, Msg: "missing key before ="}
+		}
+		if !isResultKey(key) {
+			return nil, &ResultError{Result: src, Pos: keyPos, Msg: fmt.Sprintf("invalid key %q", key)}
+		}
+
+		i = skipSpaces(src, i+1)
+		pair := resultPair{key: key, keyPos: keyPos, valuePos: i}
+		if i < len(src) && src[i] == '"' {
+			value, end, err := scanQuotedValue(src, i)
+			if err != nil {
+				return nil, err
+			}
+			pair.value, pair.quoted = value, true
+			i = skipSpaces(src, end)
+			if i < len(src) && src[i] != ',' {
+				return nil, &ResultError{Result: src, Pos: i, Msg: "unexpected text after quoted value"}
+			}
+		} else {
+			end, err := scanRawValue(src, i)
+			if err != nil {
+				return nil, err
+			}
+			pair.value = strings.TrimSpace(src[i:end])
+			i = end
+		}
+		pairs = append(pairs, pair)
+		if i >= len(src) {
+			return pairs, nil
+		}
+	}
+}
+
+// scanQuotedValue reads the quoted string starting at start, returning its unescaped text and
+// the offset just past the closing quote.
+func scanQuotedValue(src string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src) && (src[i+1] == '"' || src[i+1] == '\\'):
+			i++
+			b.WriteByte(src[i])
+		case c == '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &ResultError{Result: src, Pos: start, Msg: "unterminated string"}
+}
+
+// scanRawValue returns the end of the unquoted value starting at start: the next comma outside
+// parentheses, brackets and double quotes, or the end of src.
+func scanRawValue(src string, start int) (int, error) {
+	var open []int
+	quote := -1
+	for i := start; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote >= 0:
+			if c == '\\' && i+1 < len(src) {
+				i++
+			} else if c == '"' {
+				quote = -1
+			}
+		case c == '"':
+			quote = i
+		case c == '(' || c == '[':
+			open = append(open, i)
+		case c == ')' || c == ']':
+			if len(open) == 0 {
+				return 0, &ResultError{Result: src, Pos: i, Msg: fmt.Sprintf("unexpected %q", c)}
+			}
+			open = open[:len(open)-1]
+		case c == ',' && len(open) == 0:
+			return i, nil
+		}
+	}
+	if quote >= 0 {
+		return 0, &ResultError{Result: src, Pos: quote, Msg: "unterminated string"}
+	}
+	if len(open) > 0 {
+		pos := open[len(open)-1]
+		return 0, &ResultError{Result: src, Pos: pos, Msg: fmt.Sprintf("unclosed %q", src[pos])}
+	}
+	return len(src), nil
+}
+
+func isResultKey(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func skipSpaces(src string, i int) int {
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\n' || src[i] == '\r') {
+		i++
+	}
+	return i
+}
@@ -1,21 +1,147 @@
 package policy
 
-const StartNodeID = "start"
+import "sync"
+
+const (
+	StartNodeID = "start"
+
+	// MaxStepsAttr is the DOT graph attribute (e.g. `graph [max_steps=20]`) that enables loop-aware execution.
+	MaxStepsAttr = "max_steps"
+
+	// ElseCond is the edge condition (`cond="else"`) that marks a default edge, like `default=true`.
+	ElseCond = "else"
+)
+
+// Termination explains why execution stopped at InferResponse.Node.
+type Termination string
+
+const (
+	TerminationNoMatchingEdge Termination = "no_matching_edge"
+	TerminationCycleDetected  Termination = "cycle_detected"
+	TerminationUndeclaredNode Termination = "undeclared_node"
+)
+
+// Severity tells whether a Finding makes a policy invalid (error) or only suspicious (warning).
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// FindingCode identifies the kind of problem a Finding reports.
+type FindingCode string
+
+const (
+	FindingNoStartNode     FindingCode = "no_start_node"
+	FindingInvalidMaxSteps FindingCode = "invalid_max_steps"
+	FindingInvalidCond     FindingCode = "invalid_condition"
+	FindingInvalidResult   FindingCode = "invalid_result"
+	FindingDuplicateNode   FindingCode = "duplicate_node"
+	FindingUndeclaredNode  FindingCode = "undeclared_node"
+	FindingUnreachableNode FindingCode = "unreachable_node"
+	FindingDeadEnd         FindingCode = "dead_end"
+	FindingMultipleDefault FindingCode = "multiple_default_edges"
+
+	FindingOverlappingConditions FindingCode = "overlapping_conditions"
+	FindingNonExhaustive         FindingCode = "non_exhaustive_conditions"
+)
 
 type (
+	// InferRequest names its policy either inline with PolicyDOT or by reference with
+	// PolicyID and an optional Version (0 or absent means the latest version).
 	InferRequest struct {
-		PolicyDOT string         `json:"policy_dot"`
+		PolicyDOT string         `json:"policy_dot,omitempty"`
+		PolicyID  string         `json:"policy_id,omitempty"`
+		Version   int            `json:"version,omitempty"`
 		Input     map[string]any `json:"input"`
+		Explain   bool           `json:"explain,omitempty"`
+		Strict    bool           `json:"strict,omitempty"`
+		MaxSteps  int            `json:"max_steps,omitempty"`
+	}
+
+	// BatchInferRequest evaluates every entry of Inputs against one policy, named the same way as in InferRequest.
+	BatchInferRequest struct {
+		PolicyDOT string           `json:"policy_dot,omitempty"`
+		PolicyID  string           `json:"policy_id,omitempty"`
+		Version   int              `json:"version,omitempty"`
+		Inputs    []map[string]any `json:"inputs"`
+		Explain   bool             `json:"explain,omitempty"`
+		Strict    bool             `json:"strict,omitempty"`
+		MaxSteps  int              `json:"max_steps,omitempty"`
+	}
+
+	// ValidateRequest names the policy to validate the same way as InferRequest, without input.
+	ValidateRequest struct {
+		PolicyDOT string `json:"policy_dot,omitempty"`
+		PolicyID  string `json:"policy_id,omitempty"`
+		Version   int    `json:"version,omitempty"`
+		Strict    bool   `json:"strict,omitempty"`
+	}
+
+	// ValidateOptions carries the per-request switches of validation. Strict reports overlapping
+	// and non-exhaustive edge conditions as errors instead of warnings.
+	ValidateOptions struct {
+		Strict bool
+	}
+
+	// ValidationReport lists every problem found in a policy; Valid is false when any of them is an error.
+	ValidationReport struct {
+		Valid    bool      `json:"valid"`
+		Findings []Finding `json:"findings"`
+	}
+
+	// Finding locates a problem at a node (Node) or at an edge (From and To).
+	Finding struct {
+		Severity Severity    `json:"severity"`
+		Code     FindingCode `json:"code"`
+		Message  string      `json:"message"`
+		Node     string      `json:"node,omitempty"`
+		From     string      `json:"from,omitempty"`
+		To       string      `json:"to,omitempty"`
 	}
 
 	InferResponse struct {
-		Output map[string]any `json:"output"`
+		Output      map[string]any `json:"output"`
+		Node        string         `json:"node"`
+		Termination Termination    `json:"termination"`
+		Trace       []TraceStep    `json:"trace,omitempty"`
+	}
+
+	// ExecOptions carries the per-request switches that change how a graph is executed.
+	// Strict turns a revisit into a *CycleError instead of stopping with TerminationCycleDetected.
+	// MaxSteps, when positive, lowers Graph.MaxSteps, or enables loop-aware execution on a graph
+	// without one; it is capped at Graph.MaxSteps and MaxStepBudget.
+	ExecOptions struct {
+		Explain  bool
+		Strict   bool
+		MaxSteps int
 	}
 
+	// TraceStep records what happened at one visited node when explain mode is on.
+	TraceStep struct {
+		Node     string           `json:"node"`
+		Assigned map[string]any   `json:"assigned,omitempty"`
+		Edges    []EdgeEvaluation `json:"edges,omitempty"`
+	}
+
+	EdgeEvaluation struct {
+		To      string `json:"to"`
+		Cond    string `json:"cond"`
+		Default bool   `json:"default,omitempty"`
+		Result  bool   `json:"result"`
+	}
+
+	// MaxSteps > 0 switches execution to loop-aware mode: nodes may be revisited
+	// and the walk fails with ErrStepBudgetExceeded after visiting that many nodes.
 	Graph struct {
-		Nodes map[string]*Node
-		Edges []*Edge
-		Start string
+		Nodes    map[string]*Node
+		Edges    []*Edge
+		Start    string
+		MaxSteps int
+
+		compileMu sync.Mutex
+		compiled  *CompiledGraph
 	}
 
 	Node struct {
@@ -23,9 +149,25 @@ type (
 		Result string
 	}
 
+	// Edge is tried after its siblings with a higher Priority and, among equal priorities, in
+	// declaration order. A Default edge has no condition and is only taken when no sibling matches.
 	Edge struct {
-		From string
-		To   string
-		Cond string
+		From     string
+		To       string
+		Cond     string
+		Priority int
+		Default  bool
 	}
 )
+
+func (r InferRequest) Options() ExecOptions {
+	return ExecOptions{Explain: r.Explain, Strict: r.Strict, MaxSteps: r.MaxSteps}
+}
+
+func (r BatchInferRequest) Options() ExecOptions {
+	return ExecOptions{Explain: r.Explain, Strict: r.Strict, MaxSteps: r.MaxSteps}
+}
+
+func (r ValidateRequest) Options() ValidateOptions {
+	return ValidateOptions{Strict: r.Strict}
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+)
+
+type DotValidator struct {
+	functions *FunctionRegistry
+}
+
+// NewDotValidator returns a validator that checks conditions and results against functions;
+// nil means the built-ins only. It should get the same registry as the parser and executor.
+func NewDotValidator(functions *FunctionRegistry) *DotValidator {
+	return &DotValidator{functions: functions.orBuiltins()}
+}
+
+// Validate checks a policy without any input and reports every problem it finds, where
+// DotParser.Parse stops at the first one and leaves most condition errors to execution:
+//
+//   - errors: a missing start node, an invalid max_steps, nodes with more than one default
+//     edge, and conditions or results that do not parse, call unknown functions or pass
+//     arguments of the wrong type;
+//   - warnings: nodes declared more than once, edges to undeclared nodes, nodes unreachable
+//     from start, dead ends, i.e. nodes without outgoing edges that set no result, and
+//     outgoing conditions that overlap or leave inputs unmatched (see coverageFindings),
+//     which opts.Strict turns into errors.
+//
+// Only DOT that cannot be read (a syntax error or an edge priority or default that is not an
+// integer or a bool), returned as ErrInvalidPolicyDot, or a cancelled ctx fails validation itself.
+func (v DotValidator) Validate(ctx context.Context, dot string, opts ValidateOptions) (ValidationReport, error) {
+	if err := checkContext(ctx); err != nil {
+		return ValidationReport{}, err
+	}
+	astGraph, err := gographviz.ParseString(dot)
+	if err != nil {
+		return ValidationReport{}, ErrInvalidPolicyDot
+	}
+	b, err := newGraphBuilder(ctx, astGraph)
+	if err != nil {
+		return ValidationReport{}, err
+	}
+
+	report := ValidationReport{Findings: []Finding{}}
+	if _, ok := b.nodes[StartNodeID]; !ok {
+		report.add(Finding{Severity: SeverityError, Code: FindingNoStartNode, Message: fmt.Sprintf("graph has no %s node", StartNodeID)})
+	}
+	if _, err := maxStepsFromAST(astGraph); err != nil {
+		report.add(Finding{Severity: SeverityError, Code: FindingInvalidMaxSteps, Message: fmt.Sprintf("%s must be a positive integer", MaxStepsAttr)})
+	}
+	for _, id := range b.duplicates {
+		report.add(Finding{Severity: SeverityWarning, Code: FindingDuplicateNode, Node: id, Message: fmt.Sprintf("node %s is declared more than once", id)})
+	}
+	ids := slices.Sorted(maps.Keys(b.nodes))
+	for _, id := range ids {
+		if err := compileResult(b.nodes[id].Result, v.functions).err; err != nil {
+			report.add(Finding{Severity: SeverityError, Code: FindingInvalidResult, Node: id, Message: errorDetail(err)})
+		}
+	}
+	outgoing := make(map[string][]*Edge)
+	for _, edge := range b.edges {
+		if err := checkContext(ctx); err != nil {
+			return ValidationReport{}, err
+		}
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+		if err := compileCondition(edge.Cond, v.functions).err; err != nil {
+			report.add(Finding{Severity: SeverityError, Code: FindingInvalidCond, From: edge.From, To: edge.To, Message: errorDetail(err)})
+		}
+		if _, ok := b.nodes[edge.To]; !ok {
+			report.add(Finding{Severity: SeverityWarning, Code: FindingUndeclaredNode, From: edge.From, To: edge.To, Message: fmt.Sprintf("edge %s -> %s points to undeclared node %s", edge.From, edge.To, edge.To)})
+		}
+	}
+	for _, from := range slices.Sorted(maps.Keys(outgoing)) {
+		if defaults := len(slices.DeleteFunc(slices.Clone(outgoing[from]), func(e *Edge) bool { return !e.Default })); defaults > 1 {
+			report.add(Finding{Severity: SeverityError, Code: FindingMultipleDefault, Node: from, Message: fmt.Sprintf("node %s has %d default edges; at most one is allowed", from, defaults)})
+		}
+	}
+	if _, ok := b.nodes[StartNodeID]; ok {
+		reachable := reachableFrom(StartNodeID, outgoing)
+		for _, id := range ids {
+			if !reachable[id] {
+				report.add(Finding{Severity: SeverityWarning, Code: FindingUnreachableNode, Node: id, Message: fmt.Sprintf("node %s is not reachable from %s", id, StartNodeID)})
+			}
+		}
+	}
+	for _, id := range ids {
+		if len(outgoing[id]) == 0 && strings.TrimSpace(b.nodes[id].Result) == "" {
+			report.add(Finding{Severity: SeverityWarning, Code: FindingDeadEnd, Node: id, Message: fmt.Sprintf("node %s has no outgoing edges and sets no result", id)})
+		}
+	}
+	coverageSeverity := SeverityWarning
+	if opts.Strict {
+		coverageSeverity = SeverityError
+	}
+	for _, id := range slices.Sorted(maps.Keys(outgoing)) {
+		if err := checkContext(ctx); err != nil {
+			return ValidationReport{}, err
+		}
+		report.Findings = append(report.Findings, coverageFindings(id, outgoing[id], v.functions, coverageSeverity)...)
+	}
+	report.Valid = !slices.ContainsFunc(report.Findings, func(f Finding) bool { return f.Severity == SeverityError })
+	return report, nil
+}
+
+func (r *ValidationReport) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+func reachableFrom(start string, outgoing map[string][]*Edge) map[string]bool {
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range outgoing[current] {
+			if !seen[edge.To] {
+				seen[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return seen
+}
+
+// errorDetail describes a condition or result error without its sentinel prefix.
+func errorDetail(err error) string {
+	var condErr *ConditionError
+	if errors.As(err, &condErr) {
+		return condErr.Detail()
+	}
+	var resultErr *ResultError
+	if errors.As(err, &resultErr) {
+		return resultErr.Detail()
+	}
+	return err.Error()
+}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid policy has no findings", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; ok [result="approved=true"]; no [result="approved=false"]; start -> ok [cond="age>=18"]; start -> no [cond="age<18"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.Findings)
+	})
+	t.Run("nodes created by edges with node defaults are declared once", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { node [result="x=1"]; start -> a; a [result="y=2"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.Findings)
+	})
+	t.Run("reports every problem instead of the first", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph {
+			start [result=""];
+			ok [result="approved=true, oops"];
+			review [result=""];
+			orphan [result="x=1"];
+			ok [color=green];
+			start -> ok [cond="age >= "];
+			start -> review [cond="contains(age, 1)"];
+			start -> ghost [cond="score > 700"];
+			orphan -> ok;
+		}`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, []Finding{
+			{Severity: SeverityWarning, Code: FindingDuplicateNode, Node: "ok", Message: "node ok is declared more than once"},
+			{Severity: SeverityError, Code: FindingInvalidResult, Node: "ok", Message: `"approved=true, oops": expected = after "oops" at column 16`},
+			{Severity: SeverityError, Code: FindingInvalidCond, From: "start", To: "ok", Message: `"age >= ": expected variable or literal, found end of condition at column 8`},
+			{Severity: SeverityError, Code: FindingInvalidCond, From: "start", To: "review", Message: `"contains(age, 1)": argument 2 of contains must be string, got number at column 15`},
+			{Severity: SeverityWarning, Code: FindingUndeclaredNode, From: "start", To: "ghost", Message: "edge start -> ghost points to undeclared node ghost"},
+			{Severity: SeverityWarning, Code: FindingUnreachableNode, Node: "orphan", Message: "node orphan is not reachable from start"},
+			{Severity: SeverityWarning, Code: FindingDeadEnd, Node: "review", Message: "node review has no outgoing edges and sets no result"},
+		}, report.Findings)
+	})
+	t.Run("missing start and invalid max_steps are errors", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { max_steps=0; foo [result="x=1"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, []FindingCode{FindingNoStartNode, FindingInvalidMaxSteps}, []FindingCode{report.Findings[0].Code, report.Findings[1].Code})
+		assert.Len(t, report.Findings, 2)
+	})
+	t.Run("more than one default edge per node is an error", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [default=true]; start -> b [cond="else"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Contains(t, report.Findings, Finding{Severity: SeverityError, Code: FindingMultipleDefault, Node: "start", Message: "node start has 2 default edges; at most one is allowed"})
+	})
+	t.Run("warnings alone keep the policy valid", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result="x=1"]; start -> ghost; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		require.Len(t, report.Findings, 1)
+		assert.Equal(t, FindingUndeclaredNode, report.Findings[0].Code)
+	})
+	t.Run("overlapping and non-exhaustive conditions are warnings", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph {
+			start [result=""]; adult [result="a=1"]; senior [result="s=1"]; sp [result="sp=1"]; other [result="o=1"];
+			start -> adult [cond="age >= 18"];
+			start -> senior [cond="age >= 21"];
+			adult -> sp [cond="state == \"SP\""];
+			adult -> other [cond="state in [\"RJ\", \"MG\"]"];
+		}`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, []Finding{
+			{Severity: SeverityWarning, Code: FindingNonExhaustive, Node: "adult", Message: `no edge from adult matches when state=""`},
+			{Severity: SeverityWarning, Code: FindingOverlappingConditions, From: "start", To: "senior", Message: "edges start -> adult [age >= 18] and start -> senior [age >= 21] both match when age=21; the one declared first is taken"},
+			{Severity: SeverityWarning, Code: FindingNonExhaustive, Node: "start", Message: "no edge from start matches when age=17"},
+		}, report.Findings)
+	})
+	t.Run("strict turns coverage findings into errors", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [cond="score > 700 && age >= 18"]; start -> b [cond="score <= 700 || age < 18"]; }`
+		overlapping := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [cond="x < 10"]; start -> b [cond="x > 5"]; }`
+
+		// Act
+		clean, err := validator.Validate(context.Background(), dot, ValidateOptions{Strict: true})
+		require.NoError(t, err)
+		report, err := validator.Validate(context.Background(), overlapping, ValidateOptions{Strict: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, clean.Valid)
+		assert.Empty(t, clean.Findings)
+		assert.False(t, report.Valid)
+		require.Len(t, report.Findings, 1)
+		assert.Equal(t, SeverityError, report.Findings[0].Severity)
+		assert.Equal(t, "edges start -> a [x < 10] and start -> b [x > 5] both match when x=7.5; the one declared first is taken", report.Findings[0].Message)
+	})
+	t.Run("unconditional fallback edge makes a node exhaustive without overlapping", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [cond="x == 1"]; start -> b; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, report.Findings)
+	})
+	t.Run("priorities and default edges resolve overlaps and gaps", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; c [result="c=1"]; start -> a [cond="age >= 18"]; start -> b [cond="age >= 65", priority=1]; start -> c [cond="else"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{Strict: true})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.Findings)
+	})
+	t.Run("conditions beyond simple comparisons are not analyzed", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+		dot := `digraph { start [result=""]; a [result="a=1"]; b [result="b=1"]; start -> a [cond="len(name) > 3"]; start -> b [cond="debt / income < 0.4"]; }`
+
+		// Act
+		report, err := validator.Validate(context.Background(), dot, ValidateOptions{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, report.Findings)
+	})
+	t.Run("invalid DOT syntax returns ErrInvalidPolicyDot", func(t *testing.T) {
+		// Arrange
+		validator := NewDotValidator(nil)
+
+		// Act
+		_, err := validator.Validate(context.Background(), `digraph { start [result=]; }`, ValidateOptions{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidPolicyDot)
+	})
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const dotFileExt = ".dot"
+
+// FileStore is a PolicyStore backed by a local directory laid out as <dir>/<id>/<version>.dot.
+// A version is written to a temporary file in the policy directory and then hard-linked to its
+// final name, which fails if the name exists: two writers never overwrite the same version, and
+// readers, even in other processes, never see a missing or partly written <version>.dot.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create policy store dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, id, dot string) (PolicyVersion, error) {
+	if err := ValidateID(id); err != nil {
+		return PolicyVersion{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policyDir := filepath.Join(s.dir, id)
+	if err := os.MkdirAll(policyDir, 0o755); err != nil {
+		return PolicyVersion{}, fmt.Errorf("create policy dir: %w", err)
+	}
+	versions, err := s.versionNumbers(id)
+	if err != nil && !errors.Is(err, ErrPolicyNotFound) {
+		return PolicyVersion{}, err
+	}
+	next := len(versions) + 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1] + 1
+	}
+	tmp, err := writeTemp(policyDir, dot)
+	if err != nil {
+		return PolicyVersion{}, err
+	}
+	defer os.Remove(tmp)
+	for {
+		err := os.Link(tmp, s.versionPath(id, next))
+		if errors.Is(err, fs.ErrExist) {
+			next++
+			continue
+		}
+		if err != nil {
+			return PolicyVersion{}, fmt.Errorf("create policy version: %w", err)
+		}
+		return s.read(id, next)
+	}
+}
+
+// writeTemp writes dot to a new temporary file in dir, flushed to disk, and returns its path.
+// Its name does not end in .dot, so it is never listed as a version.
+func writeTemp(dir, dot string) (string, error) {
+	f, err := os.CreateTemp(dir, ".put-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("create policy version: %w", err)
+	}
+	_, writeErr := f.WriteString(dot)
+	if writeErr == nil {
+		writeErr = f.Sync()
+	}
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("write policy version: %w", writeErr)
+	}
+	return f.Name(), nil
+}
+
+func (s *FileStore) Get(ctx context.Context, id string, version int) (PolicyVersion, error) {
+	if err := ValidateID(id); err != nil {
+		return PolicyVersion{}, ErrPolicyNotFound
+	}
+	if version == 0 {
+		versions, err := s.versionNumbers(id)
+		if err != nil {
+			return PolicyVersion{}, err
+		}
+		version = versions[len(versions)-1]
+	}
+	v, err := s.read(id, version)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, statErr := os.Stat(filepath.Join(s.dir, id)); statErr != nil {
+			return PolicyVersion{}, ErrPolicyNotFound
+		}
+		return PolicyVersion{}, ErrVersionNotFound
+	}
+	return v, err
+}
+
+func (s *FileStore) Versions(ctx context.Context, id string) ([]PolicyVersion, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, ErrPolicyNotFound
+	}
+	versions, err := s.versionNumbers(id)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]PolicyVersion, 0, len(versions))
+	for _, n := range versions {
+		info, err := os.Stat(s.versionPath(id, n))
+		if err != nil {
+			return nil, fmt.Errorf("stat policy version: %w", err)
+		}
+		list = append(list, PolicyVersion{ID: id, Version: n, CreatedAt: info.ModTime().UTC()})
+	}
+	return list, nil
+}
+
+// versionNumbers returns the stored versions of id in ascending order.
+func (s *FileStore) versionNumbers(id string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrPolicyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read policy dir: %w", err)
+	}
+	var versions []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, dotFileExt) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(name, dotFileExt)); err == nil && n > 0 {
+			versions = append(versions, n)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrPolicyNotFound
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+func (s *FileStore) read(id string, version int) (PolicyVersion, error) {
+	path := s.versionPath(id, version)
+	dot, err := os.ReadFile(path)
+	if err != nil {
+		return PolicyVersion{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return PolicyVersion{}, err
+	}
+	return PolicyVersion{ID: id, Version: version, DOT: string(dot), CreatedAt: info.ModTime().UTC()}, nil
+}
+
+func (s *FileStore) versionPath(id string, version int) string {
+	return filepath.Join(s.dir, id, strconv.Itoa(version)+dotFileExt)
+}
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("put writes one file per version and get reads it back", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, err := NewFileStore(dir)
+		require.NoError(t, err)
+
+		// Act
+		_, err = store.Put(context.Background(), "credit", "v1")
+		require.NoError(t, err)
+		second, err := store.Put(context.Background(), "credit", "v2")
+		require.NoError(t, err)
+		latest, err := store.Get(context.Background(), "credit", 0)
+		require.NoError(t, err)
+		first, err := store.Get(context.Background(), "credit", 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, second.Version)
+		assert.Equal(t, "v2", latest.DOT)
+		assert.Equal(t, "v1", first.DOT)
+		assert.FileExists(t, filepath.Join(dir, "credit", "2.dot"))
+	})
+	t.Run("versions survive reopening the store", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, err := NewFileStore(dir)
+		require.NoError(t, err)
+		_, _ = store.Put(context.Background(), "credit", "v1")
+		_, _ = store.Put(context.Background(), "credit", "v2")
+		reopened, err := NewFileStore(dir)
+		require.NoError(t, err)
+
+		// Act
+		versions, err := reopened.Versions(context.Background(), "credit")
+		next, putErr := reopened.Put(context.Background(), "credit", "v3")
+
+		// Assert
+		require.NoError(t, err)
+		require.NoError(t, putErr)
+		require.Len(t, versions, 2)
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Empty(t, versions[1].DOT)
+		assert.Equal(t, 3, next.Version)
+	})
+	t.Run("unknown policy and version return not found errors", func(t *testing.T) {
+		// Arrange
+		store, err := NewFileStore(t.TempDir())
+		require.NoError(t, err)
+		_, _ = store.Put(context.Background(), "credit", "v1")
+
+		// Act
+		_, policyErr := store.Get(context.Background(), "other", 0)
+		_, versionErr := store.Get(context.Background(), "credit", 3)
+		_, listErr := store.Versions(context.Background(), "other")
+		_, badIDErr := store.Get(context.Background(), "../credit", 1)
+
+		// Assert
+		assert.ErrorIs(t, policyErr, ErrPolicyNotFound)
+		assert.ErrorIs(t, versionErr, ErrVersionNotFound)
+		assert.ErrorIs(t, listErr, ErrPolicyNotFound)
+		assert.ErrorIs(t, badIDErr, ErrPolicyNotFound)
+	})
+	t.Run("stores sharing a directory never overwrite or expose partial versions", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		stores := make([]*FileStore, 4)
+		for i := range stores {
+			store, err := NewFileStore(dir)
+			require.NoError(t, err)
+			stores[i] = store
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, len(stores)*10)
+
+		// Act
+		for i, store := range stores {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 10 {
+					dot := fmt.Sprintf("writer %d put %d", i, j)
+					v, err := store.Put(context.Background(), "credit", dot)
+					if err == nil && v.DOT != dot {
+						err = fmt.Errorf("version %d holds %q, want %q", v.Version, v.DOT, dot)
+					}
+					if err != nil {
+						errs <- err
+					}
+					if latest, err := store.Get(context.Background(), "credit", 0); err != nil || latest.DOT == "" {
+						errs <- fmt.Errorf("latest version %+v: %v", latest, err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// Assert
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+		versions, err := stores[0].Versions(context.Background(), "credit")
+		require.NoError(t, err)
+		assert.Len(t, versions, 40)
+		entries, err := os.ReadDir(filepath.Join(dir, "credit"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 40, "temporary files are removed")
+	})
+	t.Run("files that are not versions are ignored", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, err := NewFileStore(dir)
+		require.NoError(t, err)
+		_, _ = store.Put(context.Background(), "credit", "v1")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "credit", "notes.txt"), []byte("x"), 0o644))
+
+		// Act
+		versions, err := store.Versions(context.Background(), "credit")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, versions, 1)
+	})
+	t.Run("invalid id is rejected", func(t *testing.T) {
+		// Arrange
+		store, err := NewFileStore(t.TempDir())
+		require.NoError(t, err)
+
+		// Act
+		_, err = store.Put(context.Background(), "a/b", "v1")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidPolicyID)
+	})
+}
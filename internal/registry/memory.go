@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a PolicyStore held in process memory; it is lost when the process exits.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	policies map[string][]PolicyVersion
+	now      func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{policies: make(map[string][]PolicyVersion), now: time.Now}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, id, dot string) (PolicyVersion, error) {
+	if err := ValidateID(id); err != nil {
+		return PolicyVersion{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version := PolicyVersion{ID: id, Version: len(s.policies[id]) + 1, DOT: dot, CreatedAt: s.now().UTC()}
+	s.policies[id] = append(s.policies[id], version)
+	return version, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string, version int) (PolicyVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions, ok := s.policies[id]
+	if !ok {
+		return PolicyVersion{}, ErrPolicyNotFound
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	if version < 0 || version > len(versions) {
+		return PolicyVersion{}, ErrVersionNotFound
+	}
+	return versions[version-1], nil
+}
+
+func (s *MemoryStore) Versions(ctx context.Context, id string) ([]PolicyVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions, ok := s.policies[id]
+	if !ok {
+		return nil, ErrPolicyNotFound
+	}
+	list := make([]PolicyVersion, len(versions))
+	for i, v := range versions {
+		v.DOT = ""
+		list[i] = v
+	}
+	return list, nil
+}
@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("put assigns increasing versions and get returns latest by default", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+
+		// Act
+		first, err := store.Put(context.Background(), "credit", "digraph { start; }")
+		require.NoError(t, err)
+		second, err := store.Put(context.Background(), "credit", "digraph { start [result=\"x=1\"]; }")
+		require.NoError(t, err)
+		latest, err := store.Get(context.Background(), "credit", 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, first.Version)
+		assert.Equal(t, 2, second.Version)
+		assert.Equal(t, second, latest)
+	})
+	t.Run("get returns a specific version", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+		_, _ = store.Put(context.Background(), "credit", "v1")
+		_, _ = store.Put(context.Background(), "credit", "v2")
+
+		// Act
+		got, err := store.Get(context.Background(), "credit", 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "v1", got.DOT)
+	})
+	t.Run("unknown policy and version return not found errors", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+		_, _ = store.Put(context.Background(), "credit", "v1")
+
+		// Act
+		_, policyErr := store.Get(context.Background(), "other", 0)
+		_, versionErr := store.Get(context.Background(), "credit", 5)
+		_, listErr := store.Versions(context.Background(), "other")
+
+		// Assert
+		assert.ErrorIs(t, policyErr, ErrPolicyNotFound)
+		assert.ErrorIs(t, versionErr, ErrVersionNotFound)
+		assert.ErrorIs(t, listErr, ErrPolicyNotFound)
+	})
+	t.Run("versions lists metadata without DOT", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+		_, _ = store.Put(context.Background(), "credit", "v1")
+		_, _ = store.Put(context.Background(), "credit", "v2")
+
+		// Act
+		versions, err := store.Versions(context.Background(), "credit")
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Empty(t, versions[0].DOT)
+	})
+	t.Run("invalid id is rejected", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+
+		// Act
+		_, err := store.Put(context.Background(), "../etc", "v1")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidPolicyID)
+	})
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrPolicyNotFound  = errors.New("policy not found")
+	ErrVersionNotFound = errors.New("policy version not found")
+	ErrInvalidPolicyID = errors.New("invalid policy id")
+)
+
+var validPolicyID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,127}$`)
+
+type (
+	// PolicyStore keeps every version of a policy's DOT text. Versions start at 1 and
+	// increase by one on each Put; version 0 in Get means the latest one.
+	PolicyStore interface {
+		Put(ctx context.Context, id, dot string) (PolicyVersion, error)
+		Get(ctx context.Context, id string, version int) (PolicyVersion, error)
+		Versions(ctx context.Context, id string) ([]PolicyVersion, error)
+	}
+
+	// PolicyVersion describes one stored version. DOT is empty in Versions listings.
+	PolicyVersion struct {
+		ID        string    `json:"id"`
+		Version   int       `json:"version"`
+		DOT       string    `json:"policy_dot,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
+
+// ValidateID rejects ids that are empty, too long or not made of letters, digits, '_' and '-',
+// which also keeps them safe to use as file names.
+func ValidateID(id string) error {
+	if !validPolicyID.MatchString(id) {
+		return ErrInvalidPolicyID
+	}
+	return nil
+}
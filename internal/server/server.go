@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"policy-inference-decider/internal/apierror"
+)
+
+const (
+	envAddr            = "HTTP_ADDR"
+	envPort            = "PORT"
+	envReadTimeout     = "HTTP_READ_TIMEOUT"
+	envWriteTimeout    = "HTTP_WRITE_TIMEOUT"
+	envRequestTimeout  = "HTTP_REQUEST_TIMEOUT"
+	envMaxBodyBytes    = "HTTP_MAX_BODY_BYTES"
+	envShutdownTimeout = "HTTP_SHUTDOWN_TIMEOUT"
+
+	requestIDHeader = "X-Request-Id"
+)
+
+// LambdaHandler is the signature of handler.Handler.Infer, shared by the Lambda and HTTP entrypoints.
+type LambdaHandler func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error)
+
+// Config configures the HTTP server. RequestTimeout is the deadline each request is handled
+// under, playing the role of the Lambda invocation deadline; keep it below WriteTimeout so the
+// 504 timeout response can still be written.
+type Config struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+	MaxBodyBytes    int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":8080",
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		RequestTimeout:  25 * time.Second,
+		ShutdownTimeout: 15 * time.Second,
+		MaxBodyBytes:    6 << 20,
+	}
+}
+
+// ConfigFromEnv starts from DefaultConfig and applies HTTP_ADDR (or PORT), HTTP_READ_TIMEOUT,
+// HTTP_WRITE_TIMEOUT, HTTP_REQUEST_TIMEOUT, HTTP_SHUTDOWN_TIMEOUT and HTTP_MAX_BODY_BYTES;
+// malformed values are ignored.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if port := os.Getenv(envPort); port != "" {
+		cfg.Addr = ":" + port
+	}
+	if addr := os.Getenv(envAddr); addr != "" {
+		cfg.Addr = addr
+	}
+	durationFromEnv(envReadTimeout, &cfg.ReadTimeout)
+	durationFromEnv(envWriteTimeout, &cfg.WriteTimeout)
+	durationFromEnv(envRequestTimeout, &cfg.RequestTimeout)
+	durationFromEnv(envShutdownTimeout, &cfg.ShutdownTimeout)
+	if v, err := strconv.ParseInt(os.Getenv(envMaxBodyBytes), 10, 64); err == nil && v > 0 {
+		cfg.MaxBodyBytes = v
+	}
+	return cfg
+}
+
+func durationFromEnv(key string, dst *time.Duration) {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil && v > 0 {
+		*dst = v
+	}
+}
+
+func New(handler LambdaHandler, cfg Config) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           NewHTTPHandler(handler, cfg.MaxBodyBytes, cfg.RequestTimeout),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+	}
+}
+
+// Run serves until ctx is cancelled, then stops accepting connections and waits up to
+// shutdownTimeout for in-flight requests to finish.
+func Run(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info(fmt.Sprintf("[feature:http_server] [msg:listening] [addr:%s]", srv.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("[feature:http_server] [msg:shutting_down]")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown http server: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NewHTTPHandler adapts net/http requests to the Lambda Function URL event shape, so the same
+// Handler routes serve both entrypoints. Bodies larger than maxBodyBytes are rejected with 413.
+// Each request runs under a requestTimeout deadline, so evaluations that outlive it end with the
+// same 504 timeout as in Lambda; requestTimeout <= 0 leaves requests without a deadline.
+func NewHTTPHandler(handler LambdaHandler, maxBodyBytes int64, requestTimeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeAPIError(w, apierror.NewRequestTooLargeError())
+				return
+			}
+			writeAPIError(w, apierror.NewInvalidRequestBodyError())
+			return
+		}
+		ctx := r.Context()
+		if requestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+			defer cancel()
+		}
+		resp, err := handler(ctx, toLambdaRequest(r, body))
+		if err != nil {
+			slog.ErrorContext(r.Context(), fmt.Sprintf("[feature:http_server] [msg:handler] [err:%+v]", err))
+			writeAPIError(w, apierror.NewInternalError())
+			return
+		}
+		writeLambdaResponse(w, resp)
+	})
+}
+
+func toLambdaRequest(r *http.Request, body []byte) events.LambdaFunctionURLRequest {
+	headers := make(map[string]string, len(r.Header))
+	for key, values := range r.Header {
+		headers[strings.ToLower(key)] = strings.Join(values, ",")
+	}
+	query := make(map[string]string, len(r.URL.Query()))
+	for key, values := range r.URL.Query() {
+		query[key] = strings.Join(values, ",")
+	}
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+	return events.LambdaFunctionURLRequest{
+		RawPath:               r.URL.Path,
+		RawQueryString:        r.URL.RawQuery,
+		Headers:               headers,
+		QueryStringParameters: query,
+		Body:                  string(body),
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			RequestID: requestID,
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+}
+
+func writeLambdaResponse(w http.ResponseWriter, resp events.LambdaFunctionURLResponse) {
+	for key, value := range resp.Headers {
+		w.Header().Set(key, value)
+	}
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			writeAPIError(w, apierror.NewInternalError())
+			return
+		}
+		body = decoded
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(body)
+}
+
+func writeAPIError(w http.ResponseWriter, apiError apierror.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiError.Status)
+	_ = json.NewEncoder(w).Encode(apiError)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/apierror"
+	"policy-inference-decider/internal/handler"
+	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
+)
+
+func newInferHandler() LambdaHandler {
+	return handler.NewInferHandler(policy.NewDotParser(nil), policy.NewGraphExecutor(nil), policy.NewDotValidator(nil), registry.NewMemoryStore()).Infer
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Run("POST /infer is served by the Lambda handler", func(t *testing.T) {
+		// Arrange
+		srv := httptest.NewServer(NewHTTPHandler(newInferHandler(), 1<<20, time.Second))
+		defer srv.Close()
+		body := `{"policy_dot":"digraph { start [result=\"\"]; ok [result=\"approved=true\"]; start -> ok [cond=\"age>=18\"]; }","input":{"age":20}}`
+
+		// Act
+		resp, err := http.Post(srv.URL+"/infer", "application/json", strings.NewReader(body))
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		var out policy.InferResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		assert.Equal(t, true, out.Output["approved"])
+	})
+	t.Run("GET /ping returns pong", func(t *testing.T) {
+		// Arrange
+		srv := httptest.NewServer(NewHTTPHandler(newInferHandler(), 1<<20, time.Second))
+		defer srv.Close()
+
+		// Act
+		resp, err := http.Get(srv.URL + "/ping")
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		got, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "pong", string(got))
+	})
+	t.Run("body over the limit returns request_too_large", func(t *testing.T) {
+		// Arrange
+		srv := httptest.NewServer(NewHTTPHandler(newInferHandler(), 16, time.Second))
+		defer srv.Close()
+
+		// Act
+		resp, err := http.Post(srv.URL+"/infer", "application/json", strings.NewReader(strings.Repeat("x", 64)))
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		var apiErr apierror.APIError
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+		assert.Equal(t, apierror.CodeRequestTooLarge, apiErr.ErrorCode)
+	})
+	t.Run("request fields are mapped to the Lambda event", func(t *testing.T) {
+		// Arrange
+		var got events.LambdaFunctionURLRequest
+		h := NewHTTPHandler(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+			got = req
+			return events.LambdaFunctionURLResponse{StatusCode: http.StatusNoContent}, nil
+		}, 1<<20, time.Second)
+		req := httptest.NewRequest(http.MethodPut, "/policies/credit?dry=1", strings.NewReader("payload"))
+		req.Header.Set(requestIDHeader, "req-1")
+		rec := httptest.NewRecorder()
+
+		// Act
+		h.ServeHTTP(rec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, http.MethodPut, got.RequestContext.HTTP.Method)
+		assert.Equal(t, "/policies/credit", got.RequestContext.HTTP.Path)
+		assert.Equal(t, "req-1", got.RequestContext.RequestID)
+		assert.Equal(t, "1", got.QueryStringParameters["dry"])
+		assert.Equal(t, "payload", got.Body)
+	})
+	t.Run("requests run under the request timeout", func(t *testing.T) {
+		// Arrange
+		var deadline time.Time
+		var hasDeadline bool
+		h := NewHTTPHandler(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+			deadline, hasDeadline = ctx.Deadline()
+			return events.LambdaFunctionURLResponse{StatusCode: http.StatusNoContent}, nil
+		}, 1<<20, time.Minute)
+
+		// Act
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		// Assert
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+	t.Run("evaluation past the request timeout returns 504 timeout", func(t *testing.T) {
+		// Arrange
+		srv := httptest.NewServer(NewHTTPHandler(newInferHandler(), 1<<20, time.Nanosecond))
+		defer srv.Close()
+		body := `{"policy_dot": "digraph { start [result=\"x=1\"]; }", "input": {}}`
+
+		// Act
+		resp, err := http.Post(srv.URL+"/infer", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// Assert
+		var got apierror.APIError
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		assert.Equal(t, apierror.CodeTimeout, got.ErrorCode)
+	})
+	t.Run("base64 responses are decoded", func(t *testing.T) {
+		// Arrange
+		h := NewHTTPHandler(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+			return events.LambdaFunctionURLResponse{StatusCode: http.StatusOK, Body: base64.StdEncoding.EncodeToString([]byte("raw")), IsBase64Encoded: true}, nil
+		}, 1<<20, time.Second)
+		rec := httptest.NewRecorder()
+
+		// Act
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		// Assert
+		assert.Equal(t, "raw", rec.Body.String())
+	})
+	t.Run("handler error returns internal_error", func(t *testing.T) {
+		// Arrange
+		h := NewHTTPHandler(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+			return events.LambdaFunctionURLResponse{}, errors.New("boom")
+		}, 1<<20, time.Second)
+		rec := httptest.NewRecorder()
+
+		// Act
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("returns nil after graceful shutdown", func(t *testing.T) {
+		// Arrange
+		cfg := DefaultConfig()
+		cfg.Addr = "127.0.0.1:0"
+		srv := New(newInferHandler(), cfg)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		// Act
+		err := Run(ctx, srv, time.Second)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+	t.Run("returns listen errors", func(t *testing.T) {
+		// Arrange
+		cfg := DefaultConfig()
+		cfg.Addr = "invalid-address"
+		srv := New(newInferHandler(), cfg)
+
+		// Act
+		err := Run(context.Background(), srv, time.Second)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("reads overrides from environment", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envPort, "9000")
+		t.Setenv(envAddr, "")
+		t.Setenv(envReadTimeout, "3s")
+		t.Setenv(envWriteTimeout, "4s")
+		t.Setenv(envRequestTimeout, "2s")
+		t.Setenv(envShutdownTimeout, "5s")
+		t.Setenv(envMaxBodyBytes, "1024")
+
+		// Act
+		cfg := ConfigFromEnv()
+
+		// Assert
+		assert.Equal(t, Config{Addr: ":9000", ReadTimeout: 3 * time.Second, WriteTimeout: 4 * time.Second, RequestTimeout: 2 * time.Second, ShutdownTimeout: 5 * time.Second, MaxBodyBytes: 1024}, cfg)
+	})
+	t.Run("HTTP_ADDR wins over PORT and malformed values keep defaults", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envPort, "9000")
+		t.Setenv(envAddr, "127.0.0.1:7000")
+		t.Setenv(envReadTimeout, "fast")
+		t.Setenv(envMaxBodyBytes, "-1")
+
+		// Act
+		cfg := ConfigFromEnv()
+
+		// Assert
+		assert.Equal(t, "127.0.0.1:7000", cfg.Addr)
+		assert.Equal(t, DefaultConfig().ReadTimeout, cfg.ReadTimeout)
+		assert.Equal(t, DefaultConfig().MaxBodyBytes, cfg.MaxBodyBytes)
+	})
+}
@@ -1,15 +1,100 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"policy-inference-decider/internal/handler"
 	"policy-inference-decider/internal/policy"
+	"policy-inference-decider/internal/registry"
+	"policy-inference-decider/internal/server"
+)
+
+const (
+	envPolicyStoreDir = "POLICY_STORE_DIR"
+	envMode           = "PID_MODE"
+
+	modeLambda = "lambda"
+	modeHTTP   = "http"
+
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
+// startLambda hands the handler to the Lambda runtime; tests replace it.
+var startLambda = func(h server.LambdaHandler) { lambda.Start(h) }
+
 func main() {
-	parser := policy.NewDotParser()
-	executor := policy.NewGraphExecutor()
-	inferHandler := handler.NewInferHandler(parser, executor)
-	lambda.Start(inferHandler.Infer)
+	os.Exit(run(context.Background(), os.Args[1:], os.Stderr))
+}
+
+// run wires the handler and serves it with the entrypoint chosen by -mode or PID_MODE. The HTTP
+// server shuts down gracefully on SIGINT, SIGTERM or once ctx is cancelled.
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("policy-inference-decider", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	mode := flags.String("mode", envOrDefault(envMode, modeLambda), "entrypoint to run: lambda or http")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *mode != modeLambda && *mode != modeHTTP {
+		slog.Error(fmt.Sprintf("[feature:startup] [msg:unknown_mode] [mode:%s]", *mode))
+		return exitUsage
+	}
+
+	inferHandler, err := newInferHandler()
+	if err != nil {
+		slog.Error(fmt.Sprintf("[feature:policy_registry] [msg:init_store] [err:%+v]", err))
+		return exitError
+	}
+	if *mode == modeLambda {
+		startLambda(inferHandler.Infer)
+		return exitOK
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cfg := server.ConfigFromEnv()
+	if err := server.Run(ctx, server.New(inferHandler.Infer, cfg), cfg.ShutdownTimeout); err != nil {
+		slog.Error(fmt.Sprintf("[feature:http_server] [msg:run] [err:%+v]", err))
+		return exitError
+	}
+	return exitOK
+}
+
+// newInferHandler builds the handler shared by both entrypoints from the environment.
+func newInferHandler() (*handler.Handler, error) {
+	cacheSize, cacheTTL := handler.ParseCacheConfigFromEnv()
+	// Domain functions for conditions are registered here, before the registry is shared.
+	functions := policy.NewFunctionRegistry()
+	parser := handler.NewCachedParser(policy.NewDotParser(functions), cacheSize, cacheTTL)
+	executor := policy.NewGraphExecutor(functions)
+	store, err := newPolicyStore()
+	if err != nil {
+		return nil, err
+	}
+	return handler.NewInferHandler(parser, executor, policy.NewDotValidator(functions), store), nil
+}
+
+// newPolicyStore uses a FileStore rooted at POLICY_STORE_DIR when set, and an in-memory store otherwise.
+func newPolicyStore() (registry.PolicyStore, error) {
+	if dir := os.Getenv(envPolicyStoreDir); dir != "" {
+		return registry.NewFileStore(dir)
+	}
+	return registry.NewMemoryStore(), nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
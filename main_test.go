@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"policy-inference-decider/internal/registry"
+	"policy-inference-decider/internal/server"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("lambda mode starts the runtime with the infer handler", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envMode, "")
+		t.Setenv(envPolicyStoreDir, "")
+		var started server.LambdaHandler
+		original := startLambda
+		startLambda = func(h server.LambdaHandler) { started = h }
+		t.Cleanup(func() { startLambda = original })
+
+		// Act
+		code := run(context.Background(), nil, &bytes.Buffer{})
+
+		// Assert
+		assert.Equal(t, exitOK, code)
+		require.NotNil(t, started)
+		resp, err := started(context.Background(), events.LambdaFunctionURLRequest{
+			RequestContext: events.LambdaFunctionURLRequestContext{HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", Path: "/ping"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pong", resp.Body)
+	})
+	t.Run("http mode serves until the context is cancelled", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envMode, modeHTTP)
+		t.Setenv("HTTP_ADDR", "127.0.0.1:0")
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		// Act
+		code := run(ctx, nil, &bytes.Buffer{})
+
+		// Assert
+		assert.Equal(t, exitOK, code)
+	})
+	t.Run("http mode returns an error when the server cannot listen", func(t *testing.T) {
+		// Arrange
+		t.Setenv("HTTP_ADDR", "invalid-address")
+
+		// Act
+		code := run(context.Background(), []string{"-mode", modeHTTP}, &bytes.Buffer{})
+
+		// Assert
+		assert.Equal(t, exitError, code)
+	})
+	t.Run("unknown mode and bad flags are usage errors", func(t *testing.T) {
+		// Arrange
+		stderr := &bytes.Buffer{}
+
+		// Act
+		unknown := run(context.Background(), []string{"-mode", "grpc"}, stderr)
+		badFlag := run(context.Background(), []string{"-port", "1"}, stderr)
+
+		// Assert
+		assert.Equal(t, exitUsage, unknown)
+		assert.Equal(t, exitUsage, badFlag)
+		assert.Contains(t, stderr.String(), "flag provided but not defined")
+	})
+	t.Run("store that cannot be created is an error", func(t *testing.T) {
+		// Arrange
+		file := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(file, nil, 0o644))
+		t.Setenv(envPolicyStoreDir, filepath.Join(file, "store"))
+
+		// Act
+		code := run(context.Background(), []string{"-mode", modeLambda}, &bytes.Buffer{})
+
+		// Assert
+		assert.Equal(t, exitError, code)
+	})
+}
+
+func TestNewPolicyStore(t *testing.T) {
+	t.Run("POLICY_STORE_DIR selects a file store", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envPolicyStoreDir, t.TempDir())
+
+		// Act
+		store, err := newPolicyStore()
+
+		// Assert
+		require.NoError(t, err)
+		assert.IsType(t, &registry.FileStore{}, store)
+	})
+	t.Run("without POLICY_STORE_DIR the store is in memory", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envPolicyStoreDir, "")
+
+		// Act
+		store, err := newPolicyStore()
+
+		// Assert
+		require.NoError(t, err)
+		assert.IsType(t, &registry.MemoryStore{}, store)
+	})
+}
+
+func TestEnvOrDefault(t *testing.T) {
+	t.Run("returns the variable when set and the fallback otherwise", func(t *testing.T) {
+		// Arrange
+		t.Setenv(envMode, modeHTTP)
+
+		// Act
+		set := envOrDefault(envMode, modeLambda)
+		unset := envOrDefault("PID_UNSET_FOR_TEST", modeLambda)
+
+		// Assert
+		assert.Equal(t, modeHTTP, set)
+		assert.Equal(t, modeLambda, unset)
+	})
+}